@@ -0,0 +1,71 @@
+// Command gotel generates typed recorders for a metric catalog:
+//
+//	gotel gen -catalog metrics.yaml [-out metrics_gen.go] [-package name]
+//
+// See pkg/gen for the catalog format and the generated code.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GetSimpl/gotel/pkg/gen"
+)
+
+const usage = `usage: gotel <command> [flags]
+
+commands:
+  gen    generate typed recorders from a metric catalog, see gotel gen -h
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "gen":
+		if err := runGen(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "gotel gen: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "gotel: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// runGen writes the code generated for a catalog
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "metrics.yaml", "metric catalog, YAML, TOML or JSON")
+	out := flags.String("out", "", "generated file, the catalog path with _gen.go when empty")
+	pkg := flags.String("package", "", "package of the generated code, overrides the catalog's")
+	_ = flags.Parse(args)
+
+	catalog, err := gen.LoadCatalog(*catalogPath)
+	if err != nil {
+		return err
+	}
+	if *pkg != "" {
+		catalog.Package = *pkg
+	}
+
+	src, err := gen.Generate(catalog, filepath.Base(*catalogPath))
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(*catalogPath, filepath.Ext(*catalogPath)) + "_gen.go"
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+
+	return nil
+}
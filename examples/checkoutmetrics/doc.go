@@ -0,0 +1,5 @@
+// Package checkoutmetrics shows the typed recorders gotel gen generates for the metric
+// catalog in metrics.yaml
+package checkoutmetrics
+
+//go:generate go run ../../cmd/gotel gen -catalog metrics.yaml
@@ -0,0 +1,87 @@
+// Code generated by gotel gen from metrics.yaml. DO NOT EDIT.
+
+package checkoutmetrics
+
+import (
+	"fmt"
+
+	"github.com/GetSimpl/gotel"
+	"github.com/GetSimpl/gotel/pkg/metrics"
+)
+
+// Metric names of the catalog
+const (
+	// CheckoutOrdersPlaced is the counter checkout.orders.placed: Orders accepted by checkout
+	CheckoutOrdersPlaced metrics.MetricName = "checkout.orders.placed"
+	// CheckoutCartItems is the gauge checkout.cart.items: Items in open carts
+	CheckoutCartItems metrics.MetricName = "checkout.cart.items"
+	// CheckoutPaymentDuration is the histogram checkout.payment.duration: Time to authorize a payment with the provider
+	CheckoutPaymentDuration metrics.MetricName = "checkout.payment.duration"
+)
+
+// Definitions describe the metrics of the catalog, NewRecorder registers them
+var Definitions = []metrics.Definition{
+	{Name: CheckoutOrdersPlaced, Unit: "{order}", Description: "Orders accepted by checkout", Kind: metrics.KindCounter},
+	{Name: CheckoutCartItems, Unit: "{item}", Description: "Items in open carts", Kind: metrics.KindGauge},
+	{Name: CheckoutPaymentDuration, Unit: "s", Description: "Time to authorize a payment with the provider", Kind: metrics.KindHistogram, Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5}},
+}
+
+// Recorder records the metrics of the catalog with the labels it lists
+type Recorder struct {
+	client gotel.Gotel
+}
+
+// NewRecorder registers Definitions with client and returns a Recorder using it
+func NewRecorder(client gotel.Gotel) (*Recorder, error) {
+	if err := client.Register(Definitions...); err != nil {
+		return nil, fmt.Errorf("failed to register metric definitions: %w", err)
+	}
+
+	return &Recorder{client: client}, nil
+}
+
+// CheckoutOrdersPlacedLabels are the labels of checkout.orders.placed
+type CheckoutOrdersPlacedLabels struct {
+	PaymentMethod string // payment.method
+	Region        string // region
+}
+
+func (l CheckoutOrdersPlacedLabels) labels() map[string]string {
+	return map[string]string{
+		"payment.method": l.PaymentMethod,
+		"region":         l.Region,
+	}
+}
+
+// IncrementCheckoutOrdersPlaced increments checkout.orders.placed by 1
+func (r *Recorder) IncrementCheckoutOrdersPlaced(labels CheckoutOrdersPlacedLabels) {
+	r.client.IncrementCounter(CheckoutOrdersPlaced, "{order}", labels.labels())
+}
+
+// AddCheckoutOrdersPlaced adds delta to checkout.orders.placed
+func (r *Recorder) AddCheckoutOrdersPlaced(delta int64, labels CheckoutOrdersPlacedLabels) {
+	r.client.AddToCounter(delta, CheckoutOrdersPlaced, "{order}", labels.labels())
+}
+
+// SetCheckoutCartItems sets checkout.cart.items to value
+func (r *Recorder) SetCheckoutCartItems(value float64) {
+	r.client.SetGauge(value, CheckoutCartItems, "{item}", nil)
+}
+
+// CheckoutPaymentDurationLabels are the labels of checkout.payment.duration
+type CheckoutPaymentDurationLabels struct {
+	PaymentMethod   string // payment.method
+	PaymentProvider string // payment.provider
+}
+
+func (l CheckoutPaymentDurationLabels) labels() map[string]string {
+	return map[string]string{
+		"payment.method":   l.PaymentMethod,
+		"payment.provider": l.PaymentProvider,
+	}
+}
+
+// RecordCheckoutPaymentDuration records value in checkout.payment.duration, with the buckets of its definition
+func (r *Recorder) RecordCheckoutPaymentDuration(value float64, labels CheckoutPaymentDurationLabels) {
+	r.client.RecordHistogram(value, CheckoutPaymentDuration, "s", nil, labels.labels())
+}
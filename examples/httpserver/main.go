@@ -26,7 +26,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	otelClient, err := gotel.New(cfg)
+	otelClient, err := gotel.New(gotel.WithConfig(cfg))
 	if err != nil {
 		slog.Error("failed to create OpenTelemetry client", "err", err)
 		os.Exit(1)
@@ -7,6 +7,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 
 	"github.com/GetSimpl/gotel/pkg/client"
 	"github.com/GetSimpl/gotel/pkg/config"
@@ -18,11 +26,16 @@ import (
 // gotel is the main client for sending metrics to OpenTelemetry Collector
 // OTEL SDK automatically handles batching, buffering, and reliable delivery
 type gotel struct {
-	config          *config.Config
+	config          atomic.Pointer[config.Config] // replaced by Reload
+	configFile      string                        // reloaded on change and on reload signals
+	otelClient      client.OTelClient
 	metricsRegistry metrics.Registry
 	ctx             context.Context
 	cancel          context.CancelFunc
-	containerID     string // Cached container ID for automatic labeling
+	containerID     string                     // Cached container ID, a datapoint label with LegacyDefaultLabels
+	labels          atomic.Pointer[labelRules] // rebuilt by Reload
+	reloadMutex     sync.Mutex                 // serializes reloads from the file watcher, signals and callers
+	logger          *slog.Logger
 }
 
 type Gotel interface {
@@ -30,49 +43,111 @@ type Gotel interface {
 	AddToCounter(delta int64, name metrics.MetricName, unit metrics.Unit, labels map[string]string)
 	SetGauge(value float64, name metrics.MetricName, unit metrics.Unit, labels map[string]string)
 	RecordHistogram(value float64, name metrics.MetricName, unit metrics.Unit, buckets []float64, labels map[string]string)
+	Register(defs ...metrics.Definition) error
+	Reload(cfg *config.Config) error
 	Close() error
 }
 
-// New creates a new gotel client with the provided configuration
-func New(cfg *config.Config) (Gotel, error) {
-	if cfg == nil {
-		cfg = config.Default()
+// New creates a new gotel client, configured by config.Default() unless WithConfig is given
+func New(opts ...Option) (Gotel, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	logger.InitLogger()
+	cfg := o.config
+	if o.configFile != "" {
+		var err error
+		if cfg, err = config.LoadConfigFromFile(o.configFile); err != nil {
+			return nil, err
+		}
+	} else if cfg == nil {
+		cfg = config.Default()
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Every client logs to its own logger, the global one is only set on request like the
+	// global meter provider
+	clientLogger := o.logger
+	if clientLogger == nil {
+		clientLogger = logger.New()
+	}
+	if cfg.RegisterGlobal {
+		logger.Logger = clientLogger
+	}
+	o.client.Logger = clientLogger
+
+	// Resolve the container ID once, its detectors run concurrently and like the resource
+	// detectors give up after the detector timeout
+	detectorTimeout := cfg.ResourceDetectorTimeout
+	if detectorTimeout == 0 {
+		detectorTimeout = config.DefaultDetectorTimeout
+	}
+	containerID := meta.DetectContainerID(context.Background(), detectorTimeout)
+
+	// The container ID goes on the resource under detected and configured values, e.g. the
+	// ecs detector's, so every series of this process shares it without repeating it
+	o.client.ResourceDetectors = append([]meta.Detector{
+		meta.NewDetector("container.id", func(context.Context) ([]attribute.KeyValue, error) {
+			return []attribute.KeyValue{semconv.ContainerID(containerID)}, nil
+		}),
+	}, o.client.ResourceDetectors...)
+
 	// Create context
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create OTEL client
-	otelClient, err := client.NewOtelClient(cfg)
+	otelClient, err := client.NewOtelClientWithOptions(cfg, o.client)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to create OTEL client: %w", err)
 	}
 
 	// Create metrics registry with OTEL client
-	registry := metrics.NewRegistry(otelClient, ctx)
-
-	// Get container ID once during initialization
-	containerID := meta.GetContainerID()
+	registry := metrics.NewRegistryWithLimits(otelClient, ctx, metrics.Limits{
+		MaxHistogramBuckets: cfg.MaxHistogramBuckets,
+		Validation:          metrics.ValidationMode(cfg.MetricValidation),
+		Logger:              clientLogger,
+	})
 
 	g := &gotel{
-		config:          cfg,
+		configFile:      o.configFile,
+		otelClient:      otelClient,
 		metricsRegistry: registry,
 		ctx:             ctx,
 		cancel:          cancel,
 		containerID:     containerID,
+		logger:          clientLogger,
+	}
+	g.config.Store(cfg)
+
+	rules, err := newLabelRules(cfg, containerID)
+	if err != nil {
+		_ = registry.Close()
+		cancel()
+		return nil, err
+	}
+	g.labels.Store(rules)
+
+	if o.configFile != "" {
+		if err := config.WatchConfigFile(ctx, o.configFile, g.reloadLoaded); err != nil {
+			_ = registry.Close()
+			cancel()
+			return nil, err
+		}
+	}
+
+	if len(o.reloadSignals) > 0 {
+		g.watchSignals(o.reloadSignals)
 	}
 
 	if cfg.EnableDebug {
-		logger.Logger.Info("gotel client initialized", "endpoint", cfg.OtelEndpoint, "containerID", containerID)
-		logger.Logger.Info("OTEL SDK will automatically batch and send metrics")
+		clientLogger.Info("gotel client initialized", "endpoint", cfg.OtelEndpoint, "containerID", containerID)
+		clientLogger.Info("OTEL SDK will automatically batch and send metrics")
 	}
 
 	return g, nil
@@ -81,7 +156,12 @@ func New(cfg *config.Config) (Gotel, error) {
 // IncrementCounter is a convenience method to increment a counter by 1
 // The metric will be automatically batched and sent by OTEL SDK
 func (g *gotel) IncrementCounter(name metrics.MetricName, unit metrics.Unit, labels map[string]string) {
-	counter, err := g.metricsRegistry.GetOrCreateCounter(name, unit, g.addDefaultLabels(labels))
+	labels, ok := g.applyLabels(name, labels)
+	if !ok {
+		return
+	}
+
+	counter, err := g.metricsRegistry.GetOrCreateCounter(name, unit, labels)
 	if err != nil {
 		return
 	}
@@ -90,7 +170,12 @@ func (g *gotel) IncrementCounter(name metrics.MetricName, unit metrics.Unit, lab
 }
 
 func (g *gotel) AddToCounter(delta int64, name metrics.MetricName, unit metrics.Unit, labels map[string]string) {
-	counter, err := g.metricsRegistry.GetOrCreateCounter(name, unit, g.addDefaultLabels(labels))
+	labels, ok := g.applyLabels(name, labels)
+	if !ok {
+		return
+	}
+
+	counter, err := g.metricsRegistry.GetOrCreateCounter(name, unit, labels)
 	if err != nil {
 		return
 	}
@@ -101,7 +186,12 @@ func (g *gotel) AddToCounter(delta int64, name metrics.MetricName, unit metrics.
 // SetGauge is a convenience method to set a gauge value
 // The metric will be automatically batched and sent by OTEL SDK
 func (g *gotel) SetGauge(value float64, name metrics.MetricName, unit metrics.Unit, labels map[string]string) {
-	gauge, err := g.metricsRegistry.GetOrCreateGauge(name, unit, g.addDefaultLabels(labels))
+	labels, ok := g.applyLabels(name, labels)
+	if !ok {
+		return
+	}
+
+	gauge, err := g.metricsRegistry.GetOrCreateGauge(name, unit, labels)
 	if err != nil {
 		return
 	}
@@ -112,7 +202,12 @@ func (g *gotel) SetGauge(value float64, name metrics.MetricName, unit metrics.Un
 // RecordHistogram is a convenience method to record a value in a histogram
 // The metric will be automatically batched and sent by OTEL SDK
 func (g *gotel) RecordHistogram(value float64, name metrics.MetricName, unit metrics.Unit, buckets []float64, labels map[string]string) {
-	histogram, err := g.metricsRegistry.GetOrCreateHistogram(name, unit, buckets, g.addDefaultLabels(labels))
+	labels, ok := g.applyLabels(name, labels)
+	if !ok {
+		return
+	}
+
+	histogram, err := g.metricsRegistry.GetOrCreateHistogram(name, unit, buckets, labels)
 	if err != nil {
 		return
 	}
@@ -120,25 +215,103 @@ func (g *gotel) RecordHistogram(value float64, name metrics.MetricName, unit met
 	histogram.Record(value)
 }
 
-func (g *gotel) addDefaultLabels(labels map[string]string) map[string]string {
-	labelsCopy := make(map[string]string, len(labels)+3)
-	for k, v := range labels {
-		labelsCopy[k] = v
+// Register adds metric definitions, their description is exported as the help text and their
+// unit and buckets are used when a recording leaves them empty. Metrics already recorded
+// keep the instrument they were created with, so register definitions before recording.
+func (g *gotel) Register(defs ...metrics.Definition) error {
+	return g.metricsRegistry.Register(defs...)
+}
+
+// applyLabels returns the labels of a datapoint after the label rules of the config, or false
+// with the error logged when the datapoint must be dropped
+func (g *gotel) applyLabels(name metrics.MetricName, labels map[string]string) (map[string]string, bool) {
+	labels, err := g.labels.Load().apply(labels)
+	if err != nil {
+		g.logger.Error("dropping datapoint", "metric", string(name), "err", err.Error())
+		return nil, false
+	}
+
+	return labels, true
+}
+
+// Reload applies cfg to the running client: the exporter is rebuilt and the send interval
+// and debug setting change, while instruments and their values are kept. Settings only
+// read at startup, see config.Config.ReloadFrom, keep their value and are logged. An invalid
+// cfg is rejected with an error and the current configuration stays in place.
+func (g *gotel) Reload(cfg *config.Config) error {
+	g.reloadMutex.Lock()
+	defer g.reloadMutex.Unlock()
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	reloader, ok := g.otelClient.(client.Reloader)
+	if !ok {
+		return client.ErrReloadNotSupported
+	}
+
+	reloaded, ignored := g.config.Load().ReloadFrom(cfg)
+	if len(ignored) > 0 {
+		g.logger.Warn("configuration changes need a restart to take effect", "settings", ignored)
+	}
+
+	rules, err := newLabelRules(reloaded, g.containerID)
+	if err != nil {
+		return err
+	}
+
+	if err := reloader.Reload(reloaded); err != nil {
+		return err
+	}
+	g.config.Store(reloaded)
+	g.labels.Store(rules)
+
+	if reloaded.EnableDebug {
+		g.logger.Info("gotel configuration reloaded", "endpoint", reloaded.OtelEndpoint, "sendInterval", reloaded.SendInterval)
+	}
+
+	return nil
+}
+
+// reloadLoaded applies a freshly loaded configuration, logging why it was rejected
+func (g *gotel) reloadLoaded(cfg *config.Config, err error) {
+	if err == nil {
+		err = g.Reload(cfg)
 	}
+	if err != nil {
+		g.logger.Error("configuration reload rejected, keeping the current configuration", "err", err.Error())
+	}
+}
+
+// watchSignals reloads the configuration on every signal until the client is closed
+func (g *gotel) watchSignals(signals []os.Signal) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
 
-	// Add default labels for service, environment, and container
-	labelsCopy["service.name"] = g.config.ServiceName
-	labelsCopy["environment"] = g.config.Environment
-	labelsCopy["container.id"] = g.containerID
+	go func() {
+		defer signal.Stop(received)
 
-	return labelsCopy
+		for {
+			select {
+			case <-received:
+				if g.configFile != "" {
+					g.reloadLoaded(config.LoadConfigFromFile(g.configFile))
+				} else {
+					g.reloadLoaded(config.LoadConfig())
+				}
+			case <-g.ctx.Done():
+				return
+			}
+		}
+	}()
 }
 
 // Close gracefully shuts down the gotel client
 func (g *gotel) Close() error {
 	g.cancel()
 
-	if g.config.EnableDebug {
+	if g.config.Load().EnableDebug {
 		log.Println("Shutting down gotel client...")
 	}
 
@@ -147,7 +320,7 @@ func (g *gotel) Close() error {
 		log.Printf("Failed to flush metrics during shutdown: %v", err)
 	}
 
-	if g.config.EnableDebug {
+	if g.config.Load().EnableDebug {
 		log.Println("gotel client shut down successfully")
 	}
 
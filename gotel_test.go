@@ -0,0 +1,660 @@
+package gotel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	gotelclient "github.com/GetSimpl/gotel/pkg/client"
+	"github.com/GetSimpl/gotel/pkg/config"
+	"github.com/GetSimpl/gotel/pkg/logger"
+	"github.com/GetSimpl/gotel/pkg/meta"
+	"github.com/GetSimpl/gotel/pkg/metrics"
+)
+
+// recordingExporter keeps every exported batch in memory
+type recordingExporter struct {
+	mutex   sync.Mutex
+	exports []metricdata.ResourceMetrics
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.exports = append(e.exports, *rm)
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *recordingExporter) Shutdown(context.Context) error { return nil }
+
+// findSum returns the int64 sum data points of the named metric
+func findSum(t *testing.T, rm metricdata.ResourceMetrics, name string) []metricdata.DataPoint[int64] {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				require.True(t, ok, "%s is not an int64 sum", name)
+				return sum.DataPoints
+			}
+		}
+	}
+
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+func TestNew_WithReader(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	client, err := New(WithReader(reader))
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.IncrementCounter(metrics.MetricCounterHttpRequestsTotal, metrics.UnitRequest, map[string]string{"route": "/orders"})
+	client.AddToCounter(2, metrics.MetricCounterHttpRequestsTotal, metrics.UnitRequest, map[string]string{"route": "/orders"})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	points := findSum(t, rm, string(metrics.MetricCounterHttpRequestsTotal))
+	require.Len(t, points, 1)
+	assert.Equal(t, int64(3), points[0].Value)
+
+	route, ok := points[0].Attributes.Value("route")
+	require.True(t, ok)
+	assert.Equal(t, "/orders", route.AsString())
+
+	serviceName, ok := rm.Resource.Set().Value("service.name")
+	require.True(t, ok)
+	assert.Equal(t, config.Default().ServiceName, serviceName.AsString())
+}
+
+func TestNew_DefaultLabels(t *testing.T) {
+	t.Setenv("HOSTNAME", "checkout-7d9f8b6c5-x2x4q")
+
+	// collect returns the metrics and the container ID the client detected
+	collect := func(t *testing.T, legacy bool) (metricdata.ResourceMetrics, string) {
+		reader := sdkmetric.NewManualReader()
+
+		cfg := config.Default()
+		cfg.ServiceName = "checkout"
+		cfg.Environment = "staging"
+		cfg.LegacyDefaultLabels = legacy
+
+		client, err := New(WithConfig(cfg), WithReader(reader))
+		require.NoError(t, err)
+		defer client.Close()
+
+		client.IncrementCounter("orders.created", "{order}", map[string]string{"payment.method": "card"})
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		return rm, client.(*gotel).containerID
+	}
+
+	t.Run("resource only", func(t *testing.T) {
+		rm, detected := collect(t, false)
+
+		points := findSum(t, rm, "orders.created")
+		require.Len(t, points, 1)
+		assert.Equal(t, []attribute.KeyValue{attribute.String("payment.method", "card")}, points[0].Attributes.ToSlice())
+
+		containerID, ok := rm.Resource.Set().Value("container.id")
+		require.True(t, ok)
+		assert.Equal(t, detected, containerID.AsString())
+		environment, _ := rm.Resource.Set().Value("deployment.environment")
+		assert.Equal(t, "staging", environment.AsString())
+	})
+
+	t.Run("legacy datapoint labels", func(t *testing.T) {
+		rm, detected := collect(t, true)
+
+		points := findSum(t, rm, "orders.created")
+		require.Len(t, points, 1)
+		labels := points[0].Attributes
+		for key, expected := range map[attribute.Key]string{
+			"payment.method": "card",
+			"service.name":   "checkout",
+			"environment":    "staging",
+			"container.id":   detected,
+		} {
+			value, ok := labels.Value(key)
+			require.True(t, ok, key)
+			assert.Equal(t, expected, value.AsString(), key)
+		}
+
+		_, ok := rm.Resource.Set().Value("container.id")
+		assert.True(t, ok, "the resource keeps the container ID")
+	})
+}
+
+func TestNew_LabelRules(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	cfg := config.Default()
+	cfg.StaticLabels = map[string]string{"team": "payments"}
+	cfg.LabelCollision = config.LabelCollisionError
+	cfg.LabelDeny = []string{"user.*"}
+
+	client, err := New(WithConfig(cfg), WithReader(reader))
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.IncrementCounter("orders.created", "{order}", map[string]string{"route": "/orders", "user.id": "42"})
+	client.IncrementCounter("orders.created", "{order}", map[string]string{"route": "/orders", "team": "checkout"})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var series []map[string]string
+	for _, point := range findSum(t, rm, "orders.created") {
+		labels := make(map[string]string)
+		for _, attr := range point.Attributes.ToSlice() {
+			labels[string(attr.Key)] = attr.Value.AsString()
+		}
+		series = append(series, labels)
+	}
+	assert.Equal(t, []map[string]string{{"route": "/orders", "team": "payments"}}, series, "the colliding datapoint is dropped")
+}
+
+func TestNew_WithExporterAndResource(t *testing.T) {
+	exporter := &recordingExporter{}
+	res := resource.NewSchemaless(attribute.String("service.name", "injected"))
+
+	cfg := config.Default()
+	cfg.ServiceName = "from-config"
+
+	client, err := New(WithConfig(cfg), WithExporter(exporter), WithResource(res))
+	require.NoError(t, err)
+
+	client.IncrementCounter("jobs.processed", "{job}", nil)
+
+	// Close flushes the pending data through the exporter
+	require.NoError(t, client.Close())
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	require.NotEmpty(t, exporter.exports)
+	rm := exporter.exports[len(exporter.exports)-1]
+	assert.Equal(t, res, rm.Resource)
+	assert.Equal(t, int64(1), findSum(t, rm, "jobs.processed")[0].Value)
+}
+
+func TestNew_WithMeterProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	client, err := New(WithMeterProvider(provider))
+	require.NoError(t, err)
+
+	client.IncrementCounter("jobs.processed", "{job}", nil)
+	require.NoError(t, client.Close())
+
+	// The provider belongs to the caller and keeps working after Close
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	assert.Equal(t, int64(1), findSum(t, rm, "jobs.processed")[0].Value)
+}
+
+func TestNew_WithLogger(t *testing.T) {
+	var logs bytes.Buffer
+
+	cfg := config.Default()
+	cfg.EnableDebug = true
+
+	global := logger.Logger
+
+	client, err := New(
+		WithConfig(cfg),
+		WithReader(sdkmetric.NewManualReader()),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The registry logs to the client's logger as well
+	client.IncrementCounter("Orders Placed", "{order}", nil)
+
+	assert.Contains(t, logs.String(), "gotel client initialized")
+	assert.Contains(t, logs.String(), "metric does not follow the OTEL naming rules")
+	assert.Same(t, global, logger.Logger, "clients must not replace the global logger")
+}
+
+// manualClock is a clock.Clock whose timers only fire when the test ticks it
+type manualClock struct {
+	now  time.Time
+	tick chan time.Time
+}
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func (c *manualClock) After(time.Duration) <-chan time.Time { return c.tick }
+
+func TestNew_WithClock(t *testing.T) {
+	exporter := &recordingExporter{}
+	clk := &manualClock{now: time.Now(), tick: make(chan time.Time)}
+
+	client, err := New(WithExporter(exporter), WithClock(clk))
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.IncrementCounter("jobs.processed", "{job}", nil)
+
+	// The send interval is 30s by default, the clock ends it right away
+	clk.tick <- clk.now
+
+	require.Eventually(t, func() bool {
+		exporter.mutex.Lock()
+		defer exporter.mutex.Unlock()
+		return len(exporter.exports) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.ServiceName = ""
+
+	_, err := New(WithConfig(cfg))
+	assert.Error(t, err)
+}
+
+func TestNew_ConfigLiteral(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	// Settings left out of a literal take their defaults, as with config.Default()
+	client, err := New(WithConfig(&config.Config{
+		OtelEndpoint:   "http://localhost:4318",
+		ServiceName:    "checkout",
+		ServiceVersion: "1.0.0",
+		Environment:    "production",
+		SendInterval:   30,
+	}), WithReader(reader))
+	require.NoError(t, err)
+
+	client.IncrementCounter("orders.placed", "{order}", nil)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	assert.Len(t, findSum(t, rm, "orders.placed"), 1)
+
+	assert.NoError(t, client.Close())
+}
+
+// otlpCollector is an OTLP/HTTP endpoint that records the metric names it receives per service
+type otlpCollector struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	metrics map[string]map[string]bool // service.name -> metric names
+}
+
+func newOTLPCollector(t *testing.T) *otlpCollector {
+	c := &otlpCollector{metrics: make(map[string]map[string]bool)}
+
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var req colmetricspb.ExportMetricsServiceRequest
+		if !assert.NoError(t, proto.Unmarshal(body, &req)) {
+			return
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		for _, rm := range req.ResourceMetrics {
+			var service string
+			for _, attr := range rm.Resource.Attributes {
+				if attr.Key == "service.name" {
+					service = attr.Value.GetStringValue()
+				}
+			}
+			if c.metrics[service] == nil {
+				c.metrics[service] = make(map[string]bool)
+			}
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					c.metrics[service][m.Name] = true
+				}
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// received returns the sorted names of the metrics received per service
+func (c *otlpCollector) received() map[string][]string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := make(map[string][]string, len(c.metrics))
+	for service, names := range c.metrics {
+		result[service] = slices.Sorted(maps.Keys(names))
+	}
+	return result
+}
+
+func TestNew_IsolatedClients(t *testing.T) {
+	global := otel.GetMeterProvider()
+
+	newClient := func(service string, endpoint string) Gotel {
+		cfg := config.Default()
+		cfg.OtelEndpoint = endpoint
+		cfg.ServiceName = service
+
+		client, err := New(WithConfig(cfg))
+		require.NoError(t, err)
+		return client
+	}
+
+	tenantA := newOTLPCollector(t)
+	tenantB := newOTLPCollector(t)
+
+	clientA := newClient("tenant-a", tenantA.URL)
+	clientB := newClient("tenant-b", tenantB.URL)
+
+	assert.Same(t, global, otel.GetMeterProvider(), "clients must not replace the global meter provider")
+
+	clientA.IncrementCounter("orders.created", "{order}", nil)
+	clientB.IncrementCounter("invoices.sent", "{invoice}", nil)
+	clientB.IncrementCounter("orders.created", "{order}", nil)
+
+	require.NoError(t, clientA.Close())
+
+	// Closing one client leaves the other working
+	clientB.IncrementCounter("invoices.sent", "{invoice}", nil)
+	require.NoError(t, clientB.Close())
+
+	assert.Equal(t, map[string][]string{"tenant-a": {"orders.created"}}, tenantA.received())
+	assert.Equal(t, map[string][]string{"tenant-b": {"invoices.sent", "orders.created"}}, tenantB.received())
+}
+
+func TestNew_RegisterGlobal(t *testing.T) {
+	global := otel.GetMeterProvider()
+	defer otel.SetMeterProvider(global)
+
+	reader := sdkmetric.NewManualReader()
+
+	globalLogger := logger.Logger
+	defer func() { logger.Logger = globalLogger }()
+	clientLogger := slog.New(slog.DiscardHandler)
+
+	cfg := config.Default()
+	cfg.RegisterGlobal = true
+
+	client, err := New(WithConfig(cfg), WithReader(reader), WithLogger(clientLogger))
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Same(t, clientLogger, logger.Logger)
+
+	// Third-party instrumentation using the global provider ends up in the client's pipeline
+	counter, err := otel.GetMeterProvider().Meter("third-party").Int64Counter("cache.hits")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 1)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	assert.Equal(t, int64(1), findSum(t, rm, "cache.hits")[0].Value)
+}
+
+func TestNew_WithConfigFile(t *testing.T) {
+	first := newOTLPCollector(t)
+	second := newOTLPCollector(t)
+
+	path := filepath.Join(t.TempDir(), "gotel.yaml")
+	// Replace the file in one step, the watcher could otherwise load it truncated
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(path+".tmp", []byte(content), 0o600))
+		require.NoError(t, os.Rename(path+".tmp", path))
+	}
+	writeConfig("exporter:\n  endpoint: " + first.URL + "\nresource:\n  service_name: checkout\n")
+
+	client, err := New(WithConfigFile(path))
+	require.NoError(t, err)
+	defer os.Remove(path) // stops the watcher
+
+	g := client.(*gotel)
+	client.IncrementCounter("orders.created", "{order}", nil)
+
+	// Moving the endpoint rebuilds the exporter, an invalid file is not applied
+	writeConfig("exporter:\n  endpoint: " + second.URL + "\nresource:\n  service_name: checkout\n")
+	require.Eventually(t, func() bool {
+		return g.config.Load().OtelEndpoint == second.URL
+	}, 5*time.Second, 10*time.Millisecond)
+
+	writeConfig("exporter:\n  endpoint: " + first.URL + "\n  compression: zstd\n")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, second.URL, g.config.Load().OtelEndpoint)
+
+	client.IncrementCounter("invoices.sent", "{invoice}", nil)
+	require.NoError(t, client.Close())
+
+	// The counter created before the reload is exported through the new exporter too
+	assert.Equal(t, map[string][]string{"checkout": {"invoices.sent", "orders.created"}}, second.received())
+	assert.Empty(t, first.received())
+}
+
+func TestNew_WithReloadSignal(t *testing.T) {
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	t.Setenv("OTEL_SEND_INTERVAL", "10")
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+
+	client, err := New(WithConfig(cfg), WithReloadSignal())
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Setenv("OTEL_SEND_INTERVAL", "5")
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("sending SIGHUP is not supported: %v", err)
+	}
+
+	require.Eventually(t, func() bool {
+		return client.(*gotel).config.Load().SendInterval == 5
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNew_Reload(t *testing.T) {
+	exporter := &recordingExporter{}
+
+	client, err := New(WithExporter(exporter))
+	require.NoError(t, err)
+
+	client.AddToCounter(2, "jobs.processed", "{job}", nil)
+
+	cfg := config.Default()
+	cfg.SendInterval = 5
+	cfg.ServiceName = "renamed"
+	require.NoError(t, client.Reload(cfg))
+
+	g := client.(*gotel)
+	assert.Equal(t, 5, g.config.Load().SendInterval)
+	assert.Equal(t, config.Default().ServiceName, g.config.Load().ServiceName, "startup-only settings are kept")
+
+	invalid := config.Default()
+	invalid.SendInterval = 0
+	assert.Error(t, client.Reload(invalid))
+	assert.Equal(t, 5, g.config.Load().SendInterval)
+
+	assert.ErrorContains(t, client.Reload(nil), "config is required")
+
+	client.AddToCounter(3, "jobs.processed", "{job}", nil)
+	require.NoError(t, client.Close())
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	rm := exporter.exports[len(exporter.exports)-1]
+	assert.Equal(t, int64(5), findSum(t, rm, "jobs.processed")[0].Value)
+}
+
+func TestNew_ReloadWithMeterProvider(t *testing.T) {
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	defer provider.Shutdown(context.Background())
+
+	client, err := New(WithMeterProvider(provider))
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.ErrorIs(t, client.Reload(config.Default()), gotelclient.ErrReloadNotSupported)
+}
+
+func TestNew_Register(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	client, err := New(WithReader(reader))
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Register(
+		metrics.Definition{Name: "orders.placed", Unit: "{order}", Description: "Orders accepted by checkout", Kind: metrics.KindCounter},
+		metrics.Definition{Name: "queue.depth", Unit: "{message}", Description: "Messages waiting in the queue", Kind: metrics.KindGauge},
+		metrics.Definition{Name: "payment.duration", Unit: "s", Description: "Time to authorize a payment", Kind: metrics.KindHistogram, Buckets: []float64{0.1, 1}},
+	))
+
+	client.IncrementCounter("orders.placed", "", nil)
+	client.SetGauge(12, "queue.depth", "{message}", nil)
+	client.RecordHistogram(0.3, "payment.duration", "", nil, nil)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	found := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = m
+		}
+	}
+	assert.Equal(t, "Orders accepted by checkout", found["orders.placed"].Description)
+	assert.Equal(t, "{order}", found["orders.placed"].Unit)
+	assert.Equal(t, "Messages waiting in the queue", found["queue.depth"].Description)
+	assert.Equal(t, "{message}", found["queue.depth"].Unit)
+	assert.Equal(t, "Time to authorize a payment", found["payment.duration"].Description)
+
+	histogram, ok := found["payment.duration"].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+	assert.Equal(t, []float64{0.1, 1}, histogram.DataPoints[0].Bounds)
+
+	assert.ErrorIs(t, client.Register(metrics.Definition{Name: "orders.placed", Kind: "summary"}), metrics.ErrInvalidDefinition)
+}
+
+func TestNew_WithViews(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	cfg := config.Default()
+	cfg.Views = []config.View{
+		{Name: "legacy_requests", Rename: "http.server.requests.total"},
+		{Name: "debug.*", Drop: true},
+	}
+
+	client, err := New(
+		WithConfig(cfg),
+		WithReader(reader),
+		WithViews(sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "checkout.*"},
+			sdkmetric.Stream{AttributeFilter: attribute.NewAllowKeysFilter("payment.method")},
+		)),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.IncrementCounter("legacy_requests", metrics.UnitRequest, nil)
+	client.IncrementCounter("debug.cache.misses", "{miss}", nil)
+	client.IncrementCounter("checkout.completed", "{checkout}", map[string]string{"payment.method": "card", "user.id": "42"})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var names []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names = append(names, m.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"http.server.requests.total", "checkout.completed"}, names)
+
+	points := findSum(t, rm, "checkout.completed")
+	require.Len(t, points, 1)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("payment.method", "card")}, points[0].Attributes.ToSlice())
+}
+
+func TestNew_ResourceDetectorsAndAttributes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	cfg := config.Default()
+	cfg.ServiceName = "checkout"
+	cfg.ResourceDetectors = []string{"host", "process"}
+	cfg.ResourceAttributes = map[string]string{"team.name": "payments", "cloud.region": "eu-west-1"}
+
+	client, err := New(
+		WithConfig(cfg),
+		WithReader(reader),
+		WithResourceAttributes(
+			attribute.String("cloud.region", "us-east-1"),
+			attribute.String("service.name", "ignored"),
+		),
+		WithResourceDetectors(meta.NewDetector("inventory", func(context.Context) ([]attribute.KeyValue, error) {
+			return []attribute.KeyValue{attribute.String("host.name", "rack-b12"), attribute.String("team.name", "ignored")}, nil
+		})),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.IncrementCounter("orders.created", "{order}", nil)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	attrs := rm.Resource.Set()
+	value := func(key attribute.Key) attribute.Value {
+		v, _ := attrs.Value(key)
+		return v
+	}
+	assert.Equal(t, "rack-b12", value("host.name").AsString(), "custom detectors run after the configured ones")
+	assert.Equal(t, int64(os.Getpid()), value("process.pid").AsInt64())
+	assert.Equal(t, "payments", value("team.name").AsString())
+	assert.Equal(t, "us-east-1", value("cloud.region").AsString(), "custom attributes override configured ones")
+	assert.Equal(t, "checkout", value("service.name").AsString(), "service settings take precedence")
+}
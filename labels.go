@@ -0,0 +1,137 @@
+package gotel
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/GetSimpl/gotel/pkg/config"
+)
+
+// labelRules puts the default and static labels of a config on datapoints and applies its
+// filters and redactions, with the patterns compiled once
+type labelRules struct {
+	defaults   map[string]string
+	collision  string
+	allow      []string
+	deny       []string
+	redactions []labelRedaction
+}
+
+type labelRedaction struct {
+	key         *regexp.Regexp // nil matches every key
+	value       *regexp.Regexp // nil replaces the whole value
+	replacement string
+}
+
+// newLabelRules compiles the label settings of cfg, a validated config
+func newLabelRules(cfg *config.Config, containerID string) (*labelRules, error) {
+	builtins := map[string]string{
+		config.DefaultLabelServiceName: cfg.ServiceName,
+		config.DefaultLabelEnvironment: cfg.Environment,
+		config.DefaultLabelContainerID: containerID,
+	}
+
+	enabled := cfg.DefaultLabels
+	if cfg.LegacyDefaultLabels {
+		enabled = []string{config.DefaultLabelServiceName, config.DefaultLabelEnvironment, config.DefaultLabelContainerID}
+	}
+
+	// Built-in labels win over static labels with the same key
+	defaults := make(map[string]string, len(cfg.StaticLabels)+len(enabled))
+	for k, v := range cfg.StaticLabels {
+		defaults[k] = v
+	}
+	for _, name := range enabled {
+		defaults[name] = builtins[name]
+	}
+
+	rules := &labelRules{
+		defaults:  defaults,
+		collision: cfg.LabelCollision,
+		allow:     cfg.LabelAllow,
+		deny:      cfg.LabelDeny,
+	}
+
+	for i, r := range cfg.LabelRedactions {
+		redaction := labelRedaction{replacement: r.Replacement}
+		if redaction.replacement == "" {
+			redaction.replacement = config.RedactedValue
+		}
+
+		var err error
+		if r.Key != "" {
+			if redaction.key, err = regexp.Compile(r.Key); err != nil {
+				return nil, fmt.Errorf("failed to compile label_redactions[%d] key: %w", i, err)
+			}
+		}
+		if r.Value != "" {
+			if redaction.value, err = regexp.Compile(r.Value); err != nil {
+				return nil, fmt.Errorf("failed to compile label_redactions[%d] value: %w", i, err)
+			}
+		}
+		rules.redactions = append(rules.redactions, redaction)
+	}
+
+	return rules, nil
+}
+
+// apply returns a copy of labels with the default and static labels added, the keys not
+// allowed removed and the values redacted
+func (r *labelRules) apply(labels map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(labels)+len(r.defaults))
+	for k, v := range labels {
+		result[k] = v
+	}
+
+	for k, v := range r.defaults {
+		if caller, ok := result[k]; ok && caller != v {
+			switch r.collision {
+			case config.LabelCollisionCaller:
+				continue
+			case config.LabelCollisionError:
+				return nil, fmt.Errorf("label %q is set by the caller and by default", k)
+			}
+		}
+		result[k] = v
+	}
+
+	for k, v := range result {
+		if !r.allowed(k) {
+			delete(result, k)
+			continue
+		}
+		result[k] = r.redact(k, v)
+	}
+
+	return result, nil
+}
+
+// allowed reports whether key passes the allow and deny lists
+func (r *labelRules) allowed(key string) bool {
+	matches := func(pattern string) bool {
+		ok, _ := path.Match(pattern, key) // patterns are validated with the config
+		return ok
+	}
+
+	if len(r.allow) > 0 && !slices.ContainsFunc(r.allow, matches) {
+		return false
+	}
+	return !slices.ContainsFunc(r.deny, matches)
+}
+
+// redact applies the redactions matching key to value, in order
+func (r *labelRules) redact(key, value string) string {
+	for _, redaction := range r.redactions {
+		if redaction.key != nil && !redaction.key.MatchString(key) {
+			continue
+		}
+		if redaction.value == nil {
+			value = redaction.replacement
+			continue
+		}
+		value = redaction.value.ReplaceAllString(value, redaction.replacement)
+	}
+	return value
+}
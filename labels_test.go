@@ -0,0 +1,114 @@
+package gotel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GetSimpl/gotel/pkg/config"
+)
+
+func TestLabelRules(t *testing.T) {
+	newRules := func(t *testing.T, modify func(cfg *config.Config)) *labelRules {
+		cfg := config.Default()
+		cfg.ServiceName = "checkout"
+		cfg.Environment = "prod"
+		modify(cfg)
+		require.NoError(t, cfg.Validate())
+
+		rules, err := newLabelRules(cfg, "c0ffee")
+		require.NoError(t, err)
+		return rules
+	}
+
+	t.Run("no defaults", func(t *testing.T) {
+		rules := newRules(t, func(*config.Config) {})
+
+		labels, err := rules.apply(map[string]string{"route": "/orders"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"route": "/orders"}, labels)
+	})
+
+	t.Run("selected defaults and static labels", func(t *testing.T) {
+		rules := newRules(t, func(cfg *config.Config) {
+			cfg.DefaultLabels = []string{config.DefaultLabelContainerID}
+			cfg.StaticLabels = map[string]string{"team": "payments", "container.id": "static"}
+		})
+
+		labels, err := rules.apply(nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "payments", "container.id": "c0ffee"}, labels, "built-in labels win over static ones")
+	})
+
+	t.Run("legacy defaults", func(t *testing.T) {
+		rules := newRules(t, func(cfg *config.Config) { cfg.LegacyDefaultLabels = true })
+
+		labels, err := rules.apply(nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"service.name": "checkout", "environment": "prod", "container.id": "c0ffee"}, labels)
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		caller := map[string]string{"team": "checkout", "route": "/orders"}
+		static := func(policy string) func(cfg *config.Config) {
+			return func(cfg *config.Config) {
+				cfg.StaticLabels = map[string]string{"team": "payments"}
+				cfg.LabelCollision = policy
+			}
+		}
+
+		labels, err := newRules(t, static(config.LabelCollisionDefault)).apply(caller)
+		require.NoError(t, err)
+		assert.Equal(t, "payments", labels["team"])
+
+		labels, err = newRules(t, static(config.LabelCollisionCaller)).apply(caller)
+		require.NoError(t, err)
+		assert.Equal(t, "checkout", labels["team"])
+
+		_, err = newRules(t, static(config.LabelCollisionError)).apply(caller)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"team"`)
+
+		_, err = newRules(t, static(config.LabelCollisionError)).apply(map[string]string{"team": "payments"})
+		assert.NoError(t, err, "the same value is not a collision")
+
+		assert.Equal(t, "checkout", caller["team"], "the caller's labels are not modified")
+	})
+
+	t.Run("allow and deny", func(t *testing.T) {
+		rules := newRules(t, func(cfg *config.Config) {
+			cfg.StaticLabels = map[string]string{"team": "payments"}
+			cfg.LabelAllow = []string{"http.*", "team"}
+			cfg.LabelDeny = []string{"http.url"}
+		})
+
+		labels, err := rules.apply(map[string]string{"http.route": "/orders", "http.url": "/orders?id=1", "user.id": "42"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"http.route": "/orders", "team": "payments"}, labels)
+	})
+
+	t.Run("redactions", func(t *testing.T) {
+		rules := newRules(t, func(cfg *config.Config) {
+			cfg.LabelRedactions = []config.LabelRedaction{
+				{Key: `^user\.email$`},
+				{Value: `\d{12}(\d{4})`, Replacement: "************$1"},
+				{Key: `^http\.`, Value: `token=[^&]+`, Replacement: "token=x"},
+			}
+		})
+
+		labels, err := rules.apply(map[string]string{
+			"user.email":  "dev@example.com",
+			"card":        "4111111111111111",
+			"http.target": "/orders?token=abc&page=2",
+			"query":       "token=abc",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"user.email":  config.RedactedValue,
+			"card":        "************1111",
+			"http.target": "/orders?token=x&page=2",
+			"query":       "token=abc",
+		}, labels)
+	})
+}
@@ -0,0 +1,127 @@
+package gotel
+
+import (
+	"log/slog"
+	"os"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/GetSimpl/gotel/pkg/client"
+	"github.com/GetSimpl/gotel/pkg/clock"
+	"github.com/GetSimpl/gotel/pkg/config"
+	"github.com/GetSimpl/gotel/pkg/meta"
+)
+
+// Option configures a gotel client created by New
+type Option func(*options)
+
+type options struct {
+	config        *config.Config
+	configFile    string
+	reloadSignals []os.Signal
+	logger        *slog.Logger
+	client        client.Options
+}
+
+// WithConfig sets the configuration, config.Default() is used without it
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithConfigFile loads the configuration from a YAML, TOML or JSON file, see
+// config.LoadConfigFromFile, and reloads it every time the file changes. It takes
+// precedence over WithConfig.
+func WithConfigFile(path string) Option {
+	return func(o *options) {
+		o.configFile = path
+	}
+}
+
+// WithReloadSignal reloads the configuration when the process receives one of signals,
+// SIGHUP when none are given. The file set by WithConfigFile is read again, without one
+// the configuration is loaded from the environment by config.LoadConfig.
+func WithReloadSignal(signals ...os.Signal) Option {
+	return func(o *options) {
+		if len(signals) == 0 {
+			signals = []os.Signal{syscall.SIGHUP}
+		}
+		o.reloadSignals = append(o.reloadSignals, signals...)
+	}
+}
+
+// WithExporter replaces the OTLP exporter. It is still exported every send interval.
+func WithExporter(exporter sdkmetric.Exporter) Option {
+	return func(o *options) {
+		o.client.Exporter = exporter
+	}
+}
+
+// WithReader replaces the periodic OTLP reader, e.g. with a sdkmetric.ManualReader in tests.
+// It can be given more than once to register several readers.
+func WithReader(reader sdkmetric.Reader) Option {
+	return func(o *options) {
+		o.client.Readers = append(o.client.Readers, reader)
+	}
+}
+
+// WithResource replaces the resource built from the service settings in the config
+func WithResource(res *resource.Resource) Option {
+	return func(o *options) {
+		o.client.Resource = res
+	}
+}
+
+// WithViews adds SDK views, e.g. built with sdkmetric.NewView, after the views of the config.
+// It can be given more than once and is ignored with WithMeterProvider.
+func WithViews(views ...sdkmetric.View) Option {
+	return func(o *options) {
+		o.client.Views = append(o.client.Views, views...)
+	}
+}
+
+// WithResourceAttributes adds attributes to the resource built from the config. They take
+// precedence over detected and configured attributes, but not over the service settings.
+func WithResourceAttributes(attrs ...attribute.KeyValue) Option {
+	return func(o *options) {
+		o.client.ResourceAttributes = append(o.client.ResourceAttributes, attrs...)
+	}
+}
+
+// WithResourceDetectors runs detectors, e.g. built with meta.NewDetector, after the ones named
+// in the config. Detectors registered with meta.RegisterDetector can be named in the config instead.
+func WithResourceDetectors(detectors ...meta.Detector) Option {
+	return func(o *options) {
+		o.client.ResourceDetectors = append(o.client.ResourceDetectors, detectors...)
+	}
+}
+
+// WithLogger sets the logger of the client instead of the default JSON logger on stderr.
+// It only replaces the global logger.Logger when the config sets RegisterGlobal.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithClock sets the clock timing the send interval and the persistent export queue, which
+// dates, expires and retries batches by it. Tests can use it to export without waiting.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.client.Clock = c
+	}
+}
+
+// WithMeterProvider makes gotel create its instruments from provider. The caller owns the
+// provider: Close flushes but does not shut it down, and exporter, reader and resource
+// options are ignored.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *options) {
+		o.client.MeterProvider = provider
+	}
+}
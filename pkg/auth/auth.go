@@ -0,0 +1,97 @@
+// Package auth attaches headers and credentials to OTLP export requests.
+// Credentials come from a TokenProvider that is asked for a token on every
+// export, so providers can rotate credentials without restarting the MeterProvider.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	DefaultHeader = "Authorization"
+	DefaultScheme = "Bearer"
+)
+
+// TokenProvider supplies the credential sent with every export request.
+// Implementations are expected to cache tokens and refresh them when needed.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts a function to the TokenProvider interface
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticToken is a TokenProvider that always returns the same token, e.g. an API key
+type StaticToken string
+
+// Token returns the static token
+func (s StaticToken) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// Options configures the headers added to export requests
+type Options struct {
+	// Headers are static headers added to every request
+	Headers map[string]string
+	// TokenProvider supplies a credential for every request, optional
+	TokenProvider TokenProvider
+	// Header is the header carrying the token, defaults to Authorization
+	Header string
+	// Scheme prefixes the token, e.g. Bearer. Leave empty to send the raw token.
+	Scheme string
+}
+
+// Transport is an http.RoundTripper that adds static headers and a fresh token to every request
+type Transport struct {
+	base    http.RoundTripper
+	options Options
+}
+
+// NewTransport wraps base so every request carries the configured headers and credentials
+func NewTransport(base http.RoundTripper, opts Options) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.Header == "" {
+		opts.Header = DefaultHeader
+	}
+
+	return &Transport{
+		base:    base,
+		options: opts,
+	}
+}
+
+// RoundTrip adds the headers to a copy of the request and sends it through the base transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+
+	for k, v := range t.options.Headers {
+		clone.Header.Set(k, v)
+	}
+
+	if t.options.TokenProvider != nil {
+		token, err := t.options.TokenProvider.Token(req.Context())
+		if err != nil {
+			// The body is owned by the transport, even when the request is never sent
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+			return nil, fmt.Errorf("failed to get auth token: %w", err)
+		}
+
+		if t.options.Scheme != "" {
+			token = t.options.Scheme + " " + token
+		}
+		clone.Header.Set(t.options.Header, token)
+	}
+
+	return t.base.RoundTrip(clone)
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    Options
+		wantHeader map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "static headers",
+			options:    Options{Headers: map[string]string{"X-API-Key": "secret", "X-Tenant": "team-a"}},
+			wantHeader: map[string]string{"X-API-Key": "secret", "X-Tenant": "team-a"},
+		},
+		{
+			name:       "bearer token",
+			options:    Options{TokenProvider: StaticToken("abc"), Scheme: DefaultScheme},
+			wantHeader: map[string]string{"Authorization": "Bearer abc"},
+		},
+		{
+			name:       "raw token in custom header",
+			options:    Options{TokenProvider: StaticToken("key-123"), Header: "DD-API-KEY"},
+			wantHeader: map[string]string{"DD-API-KEY": "key-123"},
+		},
+		{
+			name: "token provider error",
+			options: Options{TokenProvider: TokenProviderFunc(func(context.Context) (string, error) {
+				return "", errors.New("token endpoint down")
+			})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewTransport(http.DefaultTransport, tt.options)}
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			for k, v := range tt.wantHeader {
+				assert.Equal(t, v, received.Get(k))
+			}
+			assert.Empty(t, req.Header, "original request must not be modified")
+		})
+	}
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	provider := NewFileTokenProvider(path)
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := provider.Token(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("reads and trims token", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("first-token\n"), 0o600))
+
+		token, err := provider.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "first-token", token)
+	})
+
+	t.Run("picks up rotated token", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("second-token-rotated\n"), 0o600))
+		// Make sure the change is visible even on filesystems with coarse timestamps
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, future, future))
+
+		token, err := provider.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "second-token-rotated", token)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("  \n"), 0o600))
+		past := time.Now().Add(-time.Minute)
+		require.NoError(t, os.Chtimes(path, past, past))
+
+		_, err := provider.Token(context.Background())
+		assert.ErrorIs(t, err, ErrEmptyToken)
+	})
+}
+
+func TestClientCredentialsProvider(t *testing.T) {
+	var requests atomic.Int32
+	var expiresIn atomic.Int64
+	expiresIn.Store(3600)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client-id" || pass != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "metrics.write metrics.read", r.PostForm.Get("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "token-" + string(rune('0'+n)),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn.Load(),
+		})
+	}))
+	defer tokenServer.Close()
+
+	newProvider := func(secret string) *ClientCredentialsProvider {
+		return NewClientCredentialsProvider(ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "client-id",
+			ClientSecret: secret,
+			Scopes:       []string{"metrics.write", "metrics.read"},
+		})
+	}
+
+	t.Run("caches token until expiry", func(t *testing.T) {
+		requests.Store(0)
+		provider := newProvider("client-secret")
+
+		for i := 0; i < 3; i++ {
+			token, err := provider.Token(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("refreshes token close to expiry", func(t *testing.T) {
+		requests.Store(0)
+		expiresIn.Store(5) // shorter than the refresh margin
+		defer expiresIn.Store(3600)
+
+		provider := newProvider("client-secret")
+
+		first, err := provider.Token(context.Background())
+		require.NoError(t, err)
+		second, err := provider.Token(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, "token-1", first)
+		assert.Equal(t, "token-2", second)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		_, err := newProvider("wrong").Token(context.Background())
+		assert.Error(t, err)
+	})
+}
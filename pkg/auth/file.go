@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrEmptyToken = errors.New("token is empty")
+
+// FileTokenProvider reads a token from a file, such as a mounted Kubernetes secret.
+// The file is re-read whenever it changes on disk, so rotated tokens are picked up on the next export.
+type FileTokenProvider struct {
+	path    string
+	token   string
+	modTime time.Time
+	size    int64
+	mutex   sync.Mutex
+}
+
+// NewFileTokenProvider creates a provider for the token stored at path
+func NewFileTokenProvider(path string) *FileTokenProvider {
+	return &FileTokenProvider{path: path}
+}
+
+// Token returns the current content of the token file with surrounding whitespace removed
+func (p *FileTokenProvider) Token(context.Context) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat token file: %w", err)
+	}
+
+	// Serve the cached token until the file is replaced or rewritten
+	if p.token != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.token, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", ErrEmptyToken
+	}
+
+	p.token = token
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+
+	return p.token, nil
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryDelta refreshes tokens slightly before they expire so in-flight exports never carry a stale token
+const expiryDelta = 10 * time.Second
+
+// ClientCredentialsConfig describes an OAuth2 client-credentials grant
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient is used to reach the token endpoint, defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+}
+
+// ClientCredentialsProvider fetches OAuth2 access tokens with the client-credentials grant
+// and caches them until shortly before they expire
+type ClientCredentialsProvider struct {
+	config ClientCredentialsConfig
+	token  string
+	expiry time.Time
+	mutex  sync.Mutex
+}
+
+// tokenResponse is the token endpoint response defined in RFC 6749 section 5.1
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewClientCredentialsProvider creates a provider for the given client-credentials grant
+func NewClientCredentialsProvider(cfg ClientCredentialsConfig) *ClientCredentialsProvider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &ClientCredentialsProvider{config: cfg}
+}
+
+// Token returns the cached access token, requesting a new one when it is about to expire
+func (p *ClientCredentialsProvider) Token(ctx context.Context) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.token != "" && (p.expiry.IsZero() || time.Now().Add(expiryDelta).Before(p.expiry)) {
+		return p.token, nil
+	}
+
+	resp, err := p.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = resp.AccessToken
+	p.expiry = time.Time{}
+	if resp.ExpiresIn > 0 {
+		p.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	return p.token, nil
+}
+
+// fetch requests a new access token from the token endpoint
+func (p *ClientCredentialsProvider) fetch(ctx context.Context) (*tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	token := new(tokenResponse)
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, ErrEmptyToken
+	}
+
+	return token, nil
+}
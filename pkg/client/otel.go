@@ -2,8 +2,11 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -11,11 +14,15 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 
+	"github.com/GetSimpl/gotel/pkg/clock"
 	"github.com/GetSimpl/gotel/pkg/config"
 	"github.com/GetSimpl/gotel/pkg/logger"
+	"github.com/GetSimpl/gotel/pkg/meta"
+	"github.com/GetSimpl/gotel/pkg/queue"
 )
 
 type Counter interface {
@@ -33,23 +40,65 @@ type Histogram interface {
 	Record(value float64, labels map[string]string)
 }
 
+// OTelClient creates instruments. The description is exported as the metric's help text
+// and may be empty.
 type OTelClient interface {
-	CreateCounter(name, unit string) (Counter, error)
-	CreateGauge(name, unit string) (Gauge, error)
-	CreateHistogram(name, unit string, buckets []float64) (Histogram, error)
+	CreateCounter(name, unit, description string) (Counter, error)
+	CreateGauge(name, unit, description string) (Gauge, error)
+	CreateHistogram(name, unit, description string, buckets []float64) (Histogram, error)
 	Close() error
 }
 
+// Reloader is implemented by clients that can apply a new configuration at runtime
+type Reloader interface {
+	// Reload rebuilds the exporter from cfg and changes the send interval without touching
+	// existing instruments. Settings only read at startup, see config.Config.ReloadFrom,
+	// are ignored. An invalid cfg is rejected and the current configuration is kept.
+	Reload(cfg *config.Config) error
+}
+
+// ErrReloadNotSupported is returned by Reload when the client doesn't own its export pipeline
+var ErrReloadNotSupported = errors.New("reload is not supported with a custom meter provider or reader")
+
 // otelClient handles communication with OpenTelemetry Collector
 // instrument creation is stateless; caching and mutexes are managed by metrics.Registry
 type otelClient struct {
-	config        *config.Config
-	meterProvider *sdkmetric.MeterProvider
-	meter         metric.Meter
-	exporter      *otlpmetrichttp.Exporter
-	ctx           context.Context
-	cancel        context.CancelFunc
-	resource      *resource.Resource
+	config         atomic.Pointer[config.Config] // replaced by Reload
+	meterProvider  metric.MeterProvider
+	sdkProvider    *sdkmetric.MeterProvider // nil when the caller supplied the meter provider
+	meter          metric.Meter
+	reader         *exportReader // nil when the caller supplied the readers or the meter provider
+	customExporter bool          // the reader exports through a caller supplied exporter
+	ctx            context.Context
+	cancel         context.CancelFunc
+	resource       *resource.Resource
+	clock          clock.Clock
+	logger         *slog.Logger // nil when neither Options.Logger nor logger.Logger is set
+}
+
+// Options replaces parts of the client that are otherwise built from the config
+type Options struct {
+	// Exporter replaces the OTLP exporter, it is still driven by a periodic reader
+	Exporter sdkmetric.Exporter
+	// Readers replace the periodic OTLP reader, e.g. a ManualReader in tests
+	Readers []sdkmetric.Reader
+	// Resource replaces the resource built from the service settings
+	Resource *resource.Resource
+	// ResourceAttributes are added to the resource built from the config, over detected
+	// and configured attributes but under the service settings
+	ResourceAttributes []attribute.KeyValue
+	// ResourceDetectors run after the detectors named in the config
+	ResourceDetectors []meta.Detector
+	// Views are applied after the views of the config
+	Views []sdkmetric.View
+	// MeterProvider replaces the SDK meter provider. The caller owns it: Close flushes it
+	// if it supports flushing but does not shut it down, and the other options do not apply.
+	MeterProvider metric.MeterProvider
+	// Clock times the export interval and the export queue, clock.System when nil
+	Clock clock.Clock
+	// Logger receives errors and, in debug mode, export payload sizes. logger.Logger is
+	// used when nil.
+	Logger *slog.Logger
 }
 
 type counter struct {
@@ -69,77 +118,279 @@ type histogram struct {
 
 // NewOtelClient creates a new OpenTelemetry client
 func NewOtelClient(cfg *config.Config) (OTelClient, error) {
+	return NewOtelClientWithOptions(cfg, Options{})
+}
+
+// NewOtelClientWithOptions creates a new OpenTelemetry client with parts of it replaced by opts
+func NewOtelClientWithOptions(cfg *config.Config, opts Options) (OTelClient, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
-		logger.Logger.Error("error in otel client", "err", err.Error())
-	}))
-
-	// Create resource with service information
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(cfg.ServiceName),
-			semconv.ServiceVersion(cfg.ServiceVersion),
-			semconv.DeploymentEnvironment(cfg.Environment),
-		),
-	)
+	client := &otelClient{
+		meterProvider: opts.MeterProvider,
+		ctx:           ctx,
+		cancel:        cancel,
+		clock:         clock.OrSystem(opts.Clock),
+		logger:        opts.Logger,
+	}
+	if client.logger == nil {
+		client.logger = logger.Logger
+	}
+	client.config.Store(cfg)
+
+	if client.meterProvider == nil {
+		if err := client.initMeterProvider(opts); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// Globals are shared by every client in the process, so only install them on request
+	if cfg.RegisterGlobal {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			if client.logger != nil {
+				client.logger.Error("error in otel client", "err", err.Error())
+			}
+		}))
+		otel.SetMeterProvider(client.meterProvider)
+	}
+
+	// Create meter
+	client.meter = client.meterProvider.Meter(cfg.ServiceName)
+
+	// TODO: implement logger
+	if cfg.EnableDebug {
+		switch {
+		case cfg.SDKDisabled:
+			log.Printf("OTEL SDK disabled, metrics are not exported")
+		case opts.MeterProvider != nil || opts.Exporter != nil || len(opts.Readers) > 0:
+			log.Printf("OTEL client initialized with a custom meter provider, exporter or reader")
+		default:
+			log.Printf("OTEL client initialized with endpoint: %s", cfg.OtelEndpoint)
+			log.Printf("Send interval: %v", time.Second*time.Duration(cfg.SendInterval))
+			log.Printf("Export timeout: %v, retry enabled: %t", orDefault(cfg.ExportTimeout, config.DefaultExportTimeout), cfg.RetryEnabled)
+			log.Printf("Compression: gzip %t", cfg.Compression == config.CompressionGzip)
+			log.Printf("Temporality: %s", cfg.Temporality)
+		}
+	}
+
+	return client, nil
+}
+
+// initMeterProvider creates the SDK meter provider with the resource and readers from cfg and opts
+func (o *otelClient) initMeterProvider(opts Options) error {
+	cfg := o.config.Load()
+
+	res := opts.Resource
+	if res == nil {
+		detectors, err := meta.Lookup(cfg.ResourceDetectors...)
+		if err != nil {
+			return fmt.Errorf("failed to detect resource: %w", err)
+		}
+
+		// A detector that fails or times out only loses its own attributes
+		detected, err := meta.DetectWith(o.ctx, orDefault(cfg.ResourceDetectorTimeout, config.DefaultDetectorTimeout),
+			append(detectors, opts.ResourceDetectors...)...)
+		if err != nil {
+			log.Printf("Resource detection incomplete: %v", err)
+		}
+
+		// Later attributes take precedence: detected, configured, custom, then service information
+		res, err = resource.New(o.ctx,
+			resource.WithAttributes(detected.Attributes()...),
+			resource.WithAttributes(labelsToAttributes(cfg.ResourceAttributes)...),
+			resource.WithAttributes(opts.ResourceAttributes...),
+			resource.WithAttributes(
+				semconv.ServiceName(cfg.ServiceName),
+				semconv.ServiceVersion(cfg.ServiceVersion),
+				semconv.DeploymentEnvironment(cfg.Environment),
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create resource: %w", err)
+		}
+	}
+
+	providerOpts := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithView(newViews(cfg.Views)...),
+		sdkmetric.WithView(opts.Views...),
+	}
+
+	// Without a reader instruments still work but nothing is aggregated or exported
+	if !cfg.SDKDisabled {
+		readers := opts.Readers
+
+		switch {
+		case opts.Exporter != nil:
+			o.reader = newExportReader(opts.Exporter, nil, o.clock, sendInterval(cfg), orDefault(cfg.ExportTimeout, config.DefaultExportTimeout))
+			o.customExporter = true
+			readers = append(readers, o.reader)
+		case len(readers) == 0:
+			exporter, exportQueue, timeout, err := o.newExporter(cfg)
+			if err != nil {
+				return err
+			}
+			o.reader = newExportReader(exporter, exportQueue, o.clock, sendInterval(cfg), timeout)
+			readers = append(readers, o.reader)
+		}
+
+		for _, reader := range readers {
+			providerOpts = append(providerOpts, sdkmetric.WithReader(reader))
+		}
+	}
+
+	// Create meter provider with configured internal
+	o.sdkProvider = sdkmetric.NewMeterProvider(providerOpts...)
+	o.meterProvider = o.sdkProvider
+	o.resource = res
+
+	return nil
+}
+
+// newExporter creates the OTLP exporter and returns it with its export queue and the
+// timeout of a whole export
+func (o *otelClient) newExporter(cfg *config.Config) (*otlpmetrichttp.Exporter, *queue.Transport, time.Duration, error) {
+	exportTimeout := orDefault(cfg.ExportTimeout, config.DefaultExportTimeout)
+	retry := otlpmetrichttp.RetryConfig{
+		Enabled:         cfg.RetryEnabled,
+		InitialInterval: orDefault(cfg.RetryInitialInterval, config.DefaultRetryInitialInterval),
+		MaxInterval:     orDefault(cfg.RetryMaxInterval, config.DefaultRetryMaxInterval),
+		MaxElapsedTime:  orDefault(cfg.RetryMaxElapsedTime, config.DefaultRetryMaxElapsedTime),
+	}
+
+	// The exporter ignores WithTimeout once a client is supplied, so the timeout is set on the client
+	httpClient, exportQueue, err := newHTTPClient(cfg, exportTimeout, o.logger, queue.Options{
+		Dir:            cfg.QueueDir,
+		MaxBytes:       cfg.QueueMaxBytes,
+		MaxAge:         cfg.QueueMaxAge,
+		InitialBackoff: retry.InitialInterval,
+		MaxBackoff:     retry.MaxInterval,
+		RequestTimeout: exportTimeout,
+		Debug:          cfg.EnableDebug,
+		Logger:         o.logger,
+		Clock:          o.clock,
+	})
 	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, nil, 0, err
 	}
 
-	// Configure exporter options with sensible defaults
+	// Configure exporter options
 	opts := []otlpmetrichttp.Option{
 		otlpmetrichttp.WithEndpointURL(cfg.OtelEndpoint),
-		otlpmetrichttp.WithTimeout(30 * time.Second), // Default timeout
+		otlpmetrichttp.WithHTTPClient(httpClient),
+		otlpmetrichttp.WithRetry(retry),
+		otlpmetrichttp.WithTemporalitySelector(temporalitySelector(cfg.Temporality)),
+	}
+	if cfg.Compression == config.CompressionGzip {
+		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
 	}
 
 	// Create OTLP exporter
-	exporter, err := otlpmetrichttp.New(ctx, opts...)
+	exporter, err := otlpmetrichttp.New(o.ctx, opts...)
 	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		if exportQueue != nil {
+			_ = exportQueue.Close()
+		}
+		return nil, nil, 0, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
 
-	// Create meter provider with configured internal
-	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(
-			sdkmetric.NewPeriodicReader(
-				exporter,
-				sdkmetric.WithInterval(time.Second*time.Duration(cfg.SendInterval)),
-			),
-		),
-	)
+	// The reader bounds a whole export, so leave room for the exporter's retries
+	readerTimeout := exportTimeout
+	if retry.Enabled {
+		readerTimeout += retry.MaxElapsedTime
+	}
 
-	// Set global meter provider
-	otel.SetMeterProvider(meterProvider)
+	return exporter, exportQueue, readerTimeout, nil
+}
 
-	// Create meter
-	meter := meterProvider.Meter(cfg.ServiceName)
+// temporalitySelector returns the selector for a temporality preference, following the
+// OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE specification
+func temporalitySelector(preference string) sdkmetric.TemporalitySelector {
+	switch preference {
+	case config.TemporalityDelta:
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindObservableCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			default:
+				return metricdata.CumulativeTemporality
+			}
+		}
+	case config.TemporalityLowMemory:
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			default:
+				return metricdata.CumulativeTemporality
+			}
+		}
+	default:
+		return sdkmetric.DefaultTemporalitySelector
+	}
+}
 
-	client := &otelClient{
-		config:        cfg,
-		meterProvider: meterProvider,
-		meter:         meter,
-		exporter:      exporter,
-		ctx:           ctx,
-		cancel:        cancel,
-		resource:      res,
+// sendInterval returns the export interval of cfg
+func sendInterval(cfg *config.Config) time.Duration {
+	return time.Second * time.Duration(cfg.SendInterval)
+}
+
+// Reload rebuilds the OTLP exporter from cfg and applies its send interval. Instruments and
+// the data aggregated so far are kept and exported through the new exporter. If the new
+// exporter can't be built the previous configuration is restored.
+func (o *otelClient) Reload(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if o.reader == nil {
+		return ErrReloadNotSupported
 	}
 
-	// TODO: implement logger
-	if cfg.EnableDebug {
-		log.Printf("OTEL client initialized with endpoint: %s", cfg.OtelEndpoint)
-		log.Printf("Send interval: %v", 30*time.Second)
+	if o.customExporter {
+		return o.reader.reconfigure(func() error {
+			o.reader.interval = sendInterval(cfg)
+			o.reader.timeout = orDefault(cfg.ExportTimeout, config.DefaultExportTimeout)
+			o.config.Store(cfg)
+			return nil
+		})
 	}
 
-	return client, nil
+	current := o.config.Load()
+
+	return o.reader.reconfigure(func() error {
+		ctx, cancel := context.WithTimeout(o.ctx, orDefault(current.ShutdownTimeout, config.DefaultShutdownTimeout))
+		defer cancel()
+
+		if err := o.reader.closeExporter(ctx); err != nil {
+			log.Printf("Failed to shut down the previous exporter: %v", err)
+		}
+
+		exporter, exportQueue, timeout, err := o.newExporter(cfg)
+		if err != nil {
+			// The previous configuration was valid before, so the old exporter can be rebuilt
+			restored, restoredQueue, restoredTimeout, restoreErr := o.newExporter(current)
+			if restoreErr != nil {
+				return fmt.Errorf("failed to reload exporter: %w", errors.Join(err, restoreErr))
+			}
+			o.reader.exporter, o.reader.queue, o.reader.timeout = restored, restoredQueue, restoredTimeout
+			return fmt.Errorf("failed to reload exporter: %w", err)
+		}
+
+		o.reader.exporter, o.reader.queue, o.reader.timeout = exporter, exportQueue, timeout
+		o.reader.interval = sendInterval(cfg)
+		o.config.Store(cfg)
+
+		if cfg.EnableDebug {
+			log.Printf("OTEL client reloaded with endpoint: %s, send interval: %v", cfg.OtelEndpoint, o.reader.interval)
+		}
+
+		return nil
+	})
 }
 
 // CreateCounter creates a new counter instrument
-func (o *otelClient) CreateCounter(name, unit string) (Counter, error) {
-	otelCounter, err := o.meter.Int64Counter(name, metric.WithUnit(unit))
+func (o *otelClient) CreateCounter(name, unit, description string) (Counter, error) {
+	otelCounter, err := o.meter.Int64Counter(name, metric.WithUnit(unit), metric.WithDescription(description))
 	if err != nil {
 		// TODO: add error log
 		return nil, err
@@ -149,8 +400,8 @@ func (o *otelClient) CreateCounter(name, unit string) (Counter, error) {
 }
 
 // CreateGauge creates a new gauge instrument
-func (o *otelClient) CreateGauge(name, unit string) (Gauge, error) {
-	otelGauge, err := o.meter.Float64Gauge(name)
+func (o *otelClient) CreateGauge(name, unit, description string) (Gauge, error) {
+	otelGauge, err := o.meter.Float64Gauge(name, metric.WithUnit(unit), metric.WithDescription(description))
 	if err != nil {
 		// TODO: add error log
 		return nil, err
@@ -160,8 +411,8 @@ func (o *otelClient) CreateGauge(name, unit string) (Gauge, error) {
 }
 
 // CreateHistogram creates a new histogram instrument
-func (o *otelClient) CreateHistogram(name, unit string, buckets []float64) (Histogram, error) {
-	otelHistogram, err := o.meter.Float64Histogram(name, metric.WithUnit(unit), metric.WithExplicitBucketBoundaries(buckets...))
+func (o *otelClient) CreateHistogram(name, unit, description string, buckets []float64) (Histogram, error) {
+	otelHistogram, err := o.meter.Float64Histogram(name, metric.WithUnit(unit), metric.WithDescription(description), metric.WithExplicitBucketBoundaries(buckets...))
 	if err != nil {
 		// TODO: add error log
 		return nil, err
@@ -196,15 +447,20 @@ func (h *histogram) Record(value float64, labels map[string]string) {
 
 // ForceFlush forces all pending metrics to be sent
 func (o *otelClient) ForceFlush() error {
-	ctx, cancel := context.WithTimeout(o.ctx, 30*time.Second) // Default timeout
+	flusher, ok := o.meterProvider.(interface{ ForceFlush(context.Context) error })
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, orDefault(o.config.Load().FlushTimeout, config.DefaultFlushTimeout))
 	defer cancel()
 
-	return o.meterProvider.ForceFlush(ctx)
+	return flusher.ForceFlush(ctx)
 }
 
 // Close gracefully shuts down the client
 func (o *otelClient) Close() error {
-	if o.config.EnableDebug {
+	if o.config.Load().EnableDebug {
 		log.Println("Shutting down OTEL client")
 	}
 
@@ -213,20 +469,37 @@ func (o *otelClient) Close() error {
 		log.Printf("Failed to flush metrics during shutdown: %v", err)
 	}
 
+	return o.shutdown()
+}
+
+// shutdown stops the meter provider if the client owns it. The export reader closes the
+// export queue after the final export had the chance to be queued.
+func (o *otelClient) shutdown() error {
 	// Cancel context
 	o.cancel()
 
-	// Shutdown meter provider
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if o.sdkProvider == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(o.config.Load().ShutdownTimeout, config.DefaultShutdownTimeout))
 	defer cancel()
 
-	if err := o.meterProvider.Shutdown(ctx); err != nil {
+	if err := o.sdkProvider.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown meter provider: %w", err)
 	}
 
 	return nil
 }
 
+// orDefault returns d, or def when d is not set
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
 // labelsToAttributes converts a map of labels to OTEL attributes
 func labelsToAttributes(labels map[string]string) []attribute.KeyValue {
 	if len(labels) == 0 {
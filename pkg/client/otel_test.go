@@ -1,12 +1,41 @@
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/GetSimpl/gotel/pkg/auth"
 	"github.com/GetSimpl/gotel/pkg/config"
+	"github.com/GetSimpl/gotel/pkg/logger"
 )
 
 func TestNewOtelClient(t *testing.T) {
@@ -117,7 +146,7 @@ func TestOtelClient_CreateCounter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			counter, err := client.CreateCounter(tt.metricName, tt.unit)
+			counter, err := client.CreateCounter(tt.metricName, tt.unit, "")
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -166,7 +195,7 @@ func TestOtelClient_CreateGauge(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gauge, err := client.CreateGauge(tt.metricName, tt.unit)
+			gauge, err := client.CreateGauge(tt.metricName, tt.unit, "")
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -225,7 +254,7 @@ func TestOtelClient_CreateHistogram(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			histogram, err := client.CreateHistogram(tt.metricName, tt.unit, tt.buckets)
+			histogram, err := client.CreateHistogram(tt.metricName, tt.unit, "", tt.buckets)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -252,7 +281,7 @@ func TestCounter_Operations(t *testing.T) {
 	require.NoError(t, err)
 	defer client.Close()
 
-	counter, err := client.CreateCounter("test_counter", "requests")
+	counter, err := client.CreateCounter("test_counter", "requests", "")
 	require.NoError(t, err)
 
 	t.Run("Inc with no labels", func(t *testing.T) {
@@ -298,7 +327,7 @@ func TestGauge_Operations(t *testing.T) {
 	require.NoError(t, err)
 	defer client.Close()
 
-	gauge, err := client.CreateGauge("test_gauge", "bytes")
+	gauge, err := client.CreateGauge("test_gauge", "bytes", "")
 	require.NoError(t, err)
 
 	t.Run("Set positive value", func(t *testing.T) {
@@ -335,7 +364,7 @@ func TestHistogram_Operations(t *testing.T) {
 	defer client.Close()
 
 	buckets := []float64{0.1, 0.5, 1.0, 5.0}
-	histogram, err := client.CreateHistogram("test_histogram", "seconds", buckets)
+	histogram, err := client.CreateHistogram("test_histogram", "seconds", "", buckets)
 	require.NoError(t, err)
 
 	t.Run("Record positive value", func(t *testing.T) {
@@ -475,7 +504,7 @@ func TestOtelClientConcurrency(t *testing.T) {
 
 	// Test concurrent metric creation and operations
 	t.Run("concurrent counter operations", func(t *testing.T) {
-		counter, err := client.CreateCounter("concurrent_counter", "requests")
+		counter, err := client.CreateCounter("concurrent_counter", "requests", "")
 		require.NoError(t, err)
 
 		done := make(chan struct{})
@@ -495,3 +524,612 @@ func TestOtelClientConcurrency(t *testing.T) {
 		}
 	})
 }
+
+func TestOtelClient_ExportQueue(t *testing.T) {
+	logger.InitLogger()
+
+	// Collector fails on purpose until healthy is flipped
+	var healthy atomic.Bool
+	var delivered atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	queueDir := t.TempDir()
+
+	cfg := config.Default()
+	cfg.OtelEndpoint = collector.URL
+	cfg.QueueDir = queueDir
+
+	client, err := NewOtelClient(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	counter, err := client.CreateCounter("queued_counter", "requests", "")
+	require.NoError(t, err)
+	counter.Add(3, map[string]string{"route": "/"})
+
+	// The failed export is persisted instead of being dropped
+	otelClient := client.(*otelClient)
+	require.NoError(t, otelClient.ForceFlush())
+
+	files, err := os.ReadDir(queueDir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Zero(t, delivered.Load())
+
+	healthy.Store(true)
+
+	require.Eventually(t, func() bool {
+		return otelClient.reader.queue.Queue().Len() == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), delivered.Load())
+}
+
+func TestOtelClient_Retry(t *testing.T) {
+	logger.InitLogger()
+
+	tests := []struct {
+		name         string
+		retryEnabled bool
+		wantRetries  bool
+	}{
+		{name: "retry enabled", retryEnabled: true, wantRetries: true},
+		{name: "retry disabled", retryEnabled: false, wantRetries: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer collector.Close()
+
+			cfg := config.Default()
+			cfg.OtelEndpoint = collector.URL
+			cfg.RetryEnabled = tt.retryEnabled
+			cfg.RetryInitialInterval = 10 * time.Millisecond
+			cfg.RetryMaxInterval = 20 * time.Millisecond
+			cfg.RetryMaxElapsedTime = 200 * time.Millisecond
+			cfg.ExportTimeout = time.Second
+			cfg.FlushTimeout = 2 * time.Second
+
+			client, err := NewOtelClient(cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			counter, err := client.CreateCounter("retried_counter", "requests", "")
+			require.NoError(t, err)
+			counter.Inc(nil)
+
+			assert.Error(t, client.(*otelClient).ForceFlush())
+
+			if tt.wantRetries {
+				assert.Greater(t, attempts.Load(), int32(1))
+			} else {
+				assert.Equal(t, int32(1), attempts.Load())
+			}
+		})
+	}
+}
+
+func TestOtelClient_HeadersAndAuth(t *testing.T) {
+	logger.InitLogger()
+
+	var apiKey, authorization atomic.Value
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey.Store(r.Header.Get("X-API-Key"))
+		authorization.Store(r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	// The token rotates between exports without rebuilding the client
+	var token atomic.Value
+	token.Store("first")
+
+	cfg := config.Default()
+	cfg.OtelEndpoint = collector.URL
+	cfg.Headers = map[string]string{"X-API-Key": "secret"}
+	cfg.TokenProvider = auth.TokenProviderFunc(func(context.Context) (string, error) {
+		return token.Load().(string), nil
+	})
+
+	client, err := NewOtelClient(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	counter, err := client.CreateCounter("authenticated_counter", "requests", "")
+	require.NoError(t, err)
+
+	otelClient := client.(*otelClient)
+
+	counter.Inc(nil)
+	require.NoError(t, otelClient.ForceFlush())
+	assert.Equal(t, "secret", apiKey.Load())
+	assert.Equal(t, "Bearer first", authorization.Load())
+
+	token.Store("second")
+
+	counter.Inc(nil)
+	require.NoError(t, otelClient.ForceFlush())
+	assert.Equal(t, "Bearer second", authorization.Load())
+}
+
+// selfSignedClientCert returns a PEM client certificate and key for commonName that is its
+// own CA, and a pool trusting it
+func selfSignedClientCert(t *testing.T, commonName string) ([]byte, []byte, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), pool
+}
+
+func TestOtelClient_TLS(t *testing.T) {
+	logger.InitLogger()
+
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+
+	// export sends one datapoint through a client built from cfg
+	export := func(t *testing.T, cfg *config.Config) error {
+		cfg.RetryEnabled = false
+
+		client, err := NewOtelClient(cfg)
+		require.NoError(t, err)
+		defer client.Close()
+
+		counter, err := client.CreateCounter("secure_counter", "requests", "")
+		require.NoError(t, err)
+		counter.Inc(nil)
+
+		return client.(*otelClient).ForceFlush()
+	}
+
+	t.Run("CA bundle", func(t *testing.T) {
+		var exports atomic.Int32
+		collector := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			exports.Add(1)
+		}))
+		defer collector.Close()
+
+		cfg := config.Default()
+		cfg.OtelEndpoint = collector.URL
+		assert.Error(t, export(t, cfg), "the httptest certificate is not trusted by the system roots")
+
+		cfg = config.Default()
+		cfg.OtelEndpoint = collector.URL
+		cfg.TLSCAFile = writeFile("ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: collector.Certificate().Raw}))
+		require.NoError(t, export(t, cfg))
+		assert.Positive(t, exports.Load())
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		certPEM, keyPEM, clientCAs := selfSignedClientCert(t, "checkout")
+
+		var peer atomic.Value
+		collector := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer.Store(r.TLS.PeerCertificates[0].Subject.CommonName)
+		}))
+		collector.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+		collector.StartTLS()
+		defer collector.Close()
+
+		caFile := writeFile("mtls-ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: collector.Certificate().Raw}))
+
+		cfg := config.Default()
+		cfg.OtelEndpoint = collector.URL
+		cfg.TLSCAFile = caFile
+		assert.Error(t, export(t, cfg), "the collector requires a client certificate")
+
+		cfg = config.Default()
+		cfg.OtelEndpoint = collector.URL
+		cfg.TLSCAFile = caFile
+		cfg.TLSCertFile = writeFile("client.pem", certPEM)
+		cfg.TLSKeyFile = writeFile("client-key.pem", keyPEM)
+		require.NoError(t, export(t, cfg))
+		assert.Equal(t, "checkout", peer.Load())
+	})
+}
+
+func TestOtelClient_Compression(t *testing.T) {
+	tests := []struct {
+		name         string
+		compression  string
+		wantEncoding string
+	}{
+		{name: "no compression", compression: config.CompressionNone, wantEncoding: ""},
+		{name: "gzip", compression: config.CompressionGzip, wantEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+			defer logger.InitLogger()
+
+			var encoding atomic.Value
+			var uncompressed atomic.Int64
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding.Store(r.Header.Get("Content-Encoding"))
+
+				var body io.Reader = r.Body
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					reader, err := gzip.NewReader(r.Body)
+					if !assert.NoError(t, err) {
+						return
+					}
+					body = reader
+				}
+				n, err := io.Copy(io.Discard, body)
+				assert.NoError(t, err)
+				uncompressed.Store(n)
+			}))
+			defer collector.Close()
+
+			cfg := config.Default()
+			cfg.OtelEndpoint = collector.URL
+			cfg.Compression = tt.compression
+			cfg.EnableDebug = true
+
+			client, err := NewOtelClient(cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			counter, err := client.CreateCounter("compressed_counter", "requests", "")
+			require.NoError(t, err)
+			counter.Inc(map[string]string{"route": "/orders"})
+
+			require.NoError(t, client.(*otelClient).ForceFlush())
+
+			assert.Equal(t, tt.wantEncoding, encoding.Load())
+			assert.Positive(t, uncompressed.Load())
+			assert.Contains(t, logs.String(), `"msg":"exporting OTLP payload"`)
+			assert.Contains(t, logs.String(), fmt.Sprintf(`"uncompressedBytes":%d`, uncompressed.Load()))
+		})
+	}
+}
+
+func TestOtelClient_SDKDisabled(t *testing.T) {
+	logger.InitLogger()
+
+	var requests atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer collector.Close()
+
+	cfg := config.Default()
+	cfg.OtelEndpoint = collector.URL
+	cfg.SDKDisabled = true
+
+	client, err := NewOtelClient(cfg)
+	require.NoError(t, err)
+
+	counter, err := client.CreateCounter("disabled_counter", "requests", "")
+	require.NoError(t, err)
+	counter.Inc(nil)
+
+	histogram, err := client.CreateHistogram("disabled_histogram", "ms", "", []float64{1, 10})
+	require.NoError(t, err)
+	histogram.Record(5, nil)
+
+	require.NoError(t, client.(*otelClient).ForceFlush())
+	require.NoError(t, client.Close())
+	assert.Zero(t, requests.Load())
+}
+
+func TestNewViews(t *testing.T) {
+	views := newViews([]config.View{
+		{Name: "debug.*", Drop: true},
+		{Name: "http.server.*", Buckets: []float64{5, 10}, AttributeKeys: []string{"route"}},
+	})
+	require.Len(t, views, 2)
+
+	t.Run("drop", func(t *testing.T) {
+		stream, ok := views[0](sdkmetric.Instrument{Name: "debug.cache.size", Kind: sdkmetric.InstrumentKindGauge})
+		require.True(t, ok)
+		assert.Equal(t, sdkmetric.AggregationDrop{}, stream.Aggregation)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, ok := views[0](sdkmetric.Instrument{Name: "http.server.requests.total"})
+		assert.False(t, ok)
+	})
+
+	t.Run("buckets and attributes of a histogram", func(t *testing.T) {
+		stream, ok := views[1](sdkmetric.Instrument{Name: "http.server.request.duration", Kind: sdkmetric.InstrumentKindHistogram})
+		require.True(t, ok)
+		assert.Equal(t, "http.server.request.duration", stream.Name)
+		assert.Equal(t, sdkmetric.AggregationExplicitBucketHistogram{Boundaries: []float64{5, 10}}, stream.Aggregation)
+		require.NotNil(t, stream.AttributeFilter)
+		assert.True(t, stream.AttributeFilter(attribute.String("route", "/orders")))
+		assert.False(t, stream.AttributeFilter(attribute.String("user.id", "42")))
+	})
+
+	t.Run("buckets are ignored for counters", func(t *testing.T) {
+		stream, ok := views[1](sdkmetric.Instrument{Name: "http.server.requests.total", Kind: sdkmetric.InstrumentKindCounter})
+		require.True(t, ok)
+		assert.Nil(t, stream.Aggregation)
+		assert.NotNil(t, stream.AttributeFilter)
+	})
+
+	t.Run("rename and aggregation", func(t *testing.T) {
+		views := newViews([]config.View{
+			{Name: "legacy_requests", Rename: "http.server.requests.total", Aggregation: config.AggregationLastValue},
+			{Name: "queue.depth", Aggregation: config.AggregationExplicitBucketHistogram},
+			{Name: "db.query.duration", Aggregation: config.AggregationExponentialHistogram},
+		})
+
+		stream, ok := views[0](sdkmetric.Instrument{Name: "legacy_requests", Kind: sdkmetric.InstrumentKindCounter})
+		require.True(t, ok)
+		assert.Equal(t, "http.server.requests.total", stream.Name)
+		assert.Equal(t, sdkmetric.AggregationLastValue{}, stream.Aggregation)
+
+		stream, ok = views[1](sdkmetric.Instrument{Name: "queue.depth", Kind: sdkmetric.InstrumentKindCounter})
+		require.True(t, ok)
+		assert.Equal(t, sdkmetric.DefaultAggregationSelector(sdkmetric.InstrumentKindHistogram), stream.Aggregation)
+
+		stream, ok = views[1](sdkmetric.Instrument{Name: "queue.depth", Kind: sdkmetric.InstrumentKindHistogram})
+		require.True(t, ok)
+		assert.Nil(t, stream.Aggregation, "histograms keep their own buckets")
+
+		stream, ok = views[2](sdkmetric.Instrument{Name: "db.query.duration", Kind: sdkmetric.InstrumentKindHistogram})
+		require.True(t, ok)
+		assert.IsType(t, sdkmetric.AggregationBase2ExponentialHistogram{}, stream.Aggregation)
+	})
+}
+
+// otlpCollector is an OTLP/HTTP endpoint that keeps every metric it receives, in order
+type otlpCollector struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	received []*metricspb.Metric
+}
+
+func newOTLPCollector(t *testing.T) *otlpCollector {
+	c := &otlpCollector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var req colmetricspb.ExportMetricsServiceRequest
+		if !assert.NoError(t, proto.Unmarshal(body, &req)) {
+			return
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				c.received = append(c.received, sm.Metrics...)
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// metrics returns every export of the named metric
+func (c *otlpCollector) metrics(name string) []*metricspb.Metric {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var result []*metricspb.Metric
+	for _, m := range c.received {
+		if m.Name == name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// value returns the last exported value of the named int64 sum
+func (c *otlpCollector) value(name string) int64 {
+	exported := c.metrics(name)
+	if len(exported) == 0 {
+		return 0
+	}
+
+	points := exported[len(exported)-1].GetSum().GetDataPoints()
+	if len(points) == 0 {
+		return 0
+	}
+	return points[0].GetAsInt()
+}
+
+func TestOtelClient_Reload(t *testing.T) {
+	logger.InitLogger()
+
+	first := newOTLPCollector(t)
+	second := newOTLPCollector(t)
+
+	cfg := config.Default()
+	cfg.OtelEndpoint = first.URL
+
+	client, err := NewOtelClient(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	otelClient := client.(*otelClient)
+
+	counter, err := client.CreateCounter("orders", "{order}", "")
+	require.NoError(t, err)
+	counter.Add(2, nil)
+
+	require.NoError(t, otelClient.ForceFlush())
+	assert.Equal(t, int64(2), first.value("orders"))
+
+	t.Run("moves the endpoint and keeps counter state", func(t *testing.T) {
+		reloaded := *cfg
+		reloaded.OtelEndpoint = second.URL
+		reloaded.SendInterval = 5
+		require.NoError(t, otelClient.Reload(&reloaded))
+		assert.Equal(t, 5*time.Second, otelClient.reader.interval)
+
+		counter.Add(3, nil)
+		require.NoError(t, otelClient.ForceFlush())
+		assert.Equal(t, int64(5), second.value("orders"))
+		assert.Equal(t, int64(2), first.value("orders"))
+	})
+
+	t.Run("rejects an invalid config", func(t *testing.T) {
+		invalid := *otelClient.config.Load()
+		invalid.OtelEndpoint = first.URL
+		invalid.Compression = "zstd"
+
+		err := otelClient.Reload(&invalid)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid configuration")
+		assert.Equal(t, second.URL, otelClient.config.Load().OtelEndpoint)
+	})
+
+	t.Run("keeps the exporter when the new one can't be built", func(t *testing.T) {
+		broken := *otelClient.config.Load()
+		broken.OtelEndpoint = first.URL
+		broken.TLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+
+		err := otelClient.Reload(&broken)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to reload exporter")
+
+		counter.Inc(nil)
+		require.NoError(t, otelClient.ForceFlush())
+		assert.Equal(t, int64(6), second.value("orders"))
+		assert.Equal(t, int64(2), first.value("orders"))
+	})
+
+	t.Run("fails after close", func(t *testing.T) {
+		require.NoError(t, client.Close())
+		assert.ErrorIs(t, otelClient.Reload(cfg), sdkmetric.ErrReaderShutdown)
+	})
+}
+
+func TestOtelClient_Temporality(t *testing.T) {
+	logger.InitLogger()
+
+	tests := []struct {
+		preference  string
+		temporality metricspb.AggregationTemporality
+		counts      []int64 // exported counter values after adding 2 and then 3
+	}{
+		{config.TemporalityCumulative, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, []int64{2, 5}},
+		{config.TemporalityDelta, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, []int64{2, 3}},
+		{config.TemporalityLowMemory, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, []int64{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preference, func(t *testing.T) {
+			collector := newOTLPCollector(t)
+
+			cfg := config.Default()
+			cfg.OtelEndpoint = collector.URL
+			cfg.Temporality = tt.preference
+
+			client, err := NewOtelClient(cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			otelClient := client.(*otelClient)
+
+			counter, err := client.CreateCounter("orders", "{order}", "")
+			require.NoError(t, err)
+			histogram, err := client.CreateHistogram("latency", "ms", "", []float64{10, 100})
+			require.NoError(t, err)
+
+			counter.Add(2, nil)
+			histogram.Record(5, nil)
+			require.NoError(t, otelClient.ForceFlush())
+
+			counter.Add(3, nil)
+			histogram.Record(50, nil)
+			require.NoError(t, otelClient.ForceFlush())
+
+			counters := collector.metrics("orders")
+			require.Len(t, counters, 2)
+			for i, m := range counters {
+				assert.Equal(t, tt.temporality, m.GetSum().GetAggregationTemporality())
+				assert.True(t, m.GetSum().GetIsMonotonic())
+				assert.Equal(t, tt.counts[i], m.GetSum().GetDataPoints()[0].GetAsInt())
+			}
+
+			histograms := collector.metrics("latency")
+			require.Len(t, histograms, 2)
+			last := histograms[1].GetHistogram()
+			assert.Equal(t, tt.temporality, last.GetAggregationTemporality())
+			if tt.temporality == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+				assert.Equal(t, uint64(1), last.GetDataPoints()[0].GetCount())
+			} else {
+				assert.Equal(t, uint64(2), last.GetDataPoints()[0].GetCount())
+			}
+		})
+	}
+}
+
+func TestTemporalitySelector(t *testing.T) {
+	delta := temporalitySelector(config.TemporalityDelta)
+	lowMemory := temporalitySelector(config.TemporalityLowMemory)
+	cumulative := temporalitySelector(config.TemporalityCumulative)
+
+	tests := []struct {
+		kind      sdkmetric.InstrumentKind
+		delta     metricdata.Temporality
+		lowMemory metricdata.Temporality
+	}{
+		{sdkmetric.InstrumentKindCounter, metricdata.DeltaTemporality, metricdata.DeltaTemporality},
+		{sdkmetric.InstrumentKindHistogram, metricdata.DeltaTemporality, metricdata.DeltaTemporality},
+		{sdkmetric.InstrumentKindObservableCounter, metricdata.DeltaTemporality, metricdata.CumulativeTemporality},
+		{sdkmetric.InstrumentKindUpDownCounter, metricdata.CumulativeTemporality, metricdata.CumulativeTemporality},
+		{sdkmetric.InstrumentKindObservableUpDownCounter, metricdata.CumulativeTemporality, metricdata.CumulativeTemporality},
+		{sdkmetric.InstrumentKindGauge, metricdata.CumulativeTemporality, metricdata.CumulativeTemporality},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			assert.Equal(t, tt.delta, delta(tt.kind))
+			assert.Equal(t, tt.lowMemory, lowMemory(tt.kind))
+			assert.Equal(t, metricdata.CumulativeTemporality, cumulative(tt.kind))
+		})
+	}
+}
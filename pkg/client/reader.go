@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/GetSimpl/gotel/pkg/clock"
+	"github.com/GetSimpl/gotel/pkg/queue"
+)
+
+// exportReader exports every interval like sdkmetric.PeriodicReader, but its exporter,
+// interval and timeout can be replaced while the meter provider, and with it every
+// instrument and its aggregated state, stays in place
+type exportReader struct {
+	// Reader is the manual reader registered with the meter provider
+	sdkmetric.Reader
+
+	mutex    sync.Mutex // held while exporting so a reload never swaps the exporter mid-export
+	exporter sdkmetric.Exporter
+	queue    *queue.Transport // nil unless the persistent export queue is enabled
+	clock    clock.Clock
+	interval time.Duration
+	timeout  time.Duration
+	closed   bool
+
+	reset chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// newExportReader starts exporting through exporter every interval of clk. Temporality and
+// aggregation come from the first exporter and are kept by later ones.
+func newExportReader(exporter sdkmetric.Exporter, exportQueue *queue.Transport, clk clock.Clock, interval, timeout time.Duration) *exportReader {
+	r := &exportReader{
+		Reader: sdkmetric.NewManualReader(
+			sdkmetric.WithTemporalitySelector(exporter.Temporality),
+			sdkmetric.WithAggregationSelector(exporter.Aggregation),
+		),
+		exporter: exporter,
+		queue:    exportQueue,
+		clock:    clk,
+		interval: interval,
+		timeout:  timeout,
+		reset:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.run()
+
+	return r
+}
+
+// run exports every interval until the reader is shut down. The interval starts over after
+// every export and reconfiguration.
+func (r *exportReader) run() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.clock.After(r.currentInterval()):
+			if err := r.export(context.Background()); err != nil {
+				otel.Handle(err)
+			}
+		case <-r.reset:
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// currentInterval returns the export interval
+func (r *exportReader) currentInterval() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.interval
+}
+
+// export collects the aggregated metrics and sends them through the current exporter
+func (r *exportReader) export(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var rm metricdata.ResourceMetrics
+	if err := r.Reader.Collect(ctx, &rm); err != nil {
+		return err
+	}
+
+	return r.exporter.Export(ctx, &rm)
+}
+
+// reconfigure runs fn while no export is in progress and restarts the interval afterwards
+func (r *exportReader) reconfigure(fn func() error) error {
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return sdkmetric.ErrReaderShutdown
+	}
+	err := fn()
+	r.mutex.Unlock()
+
+	select {
+	case r.reset <- struct{}{}:
+	default:
+	}
+
+	return err
+}
+
+// closeExporter shuts down the current exporter and then its queue, which must not be
+// replaying while another queue opens the same directory. Must be called with the mutex held.
+func (r *exportReader) closeExporter(ctx context.Context) error {
+	err := r.exporter.Shutdown(ctx)
+
+	if r.queue != nil {
+		err = errors.Join(err, r.queue.Close())
+		r.queue = nil
+	}
+
+	return err
+}
+
+// ForceFlush exports the aggregated metrics now
+func (r *exportReader) ForceFlush(ctx context.Context) error {
+	if err := r.export(ctx); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.exporter.ForceFlush(ctx)
+}
+
+// Shutdown exports one last time and then shuts down the exporter and the export queue
+func (r *exportReader) Shutdown(ctx context.Context) error {
+	err := sdkmetric.ErrReaderShutdown
+	r.once.Do(func() {
+		close(r.done)
+		r.wg.Wait()
+
+		err = r.export(ctx)
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.closed = true
+		err = errors.Join(err, r.closeExporter(ctx), r.Reader.Shutdown(ctx))
+	})
+
+	return err
+}
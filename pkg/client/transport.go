@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/GetSimpl/gotel/pkg/auth"
+	"github.com/GetSimpl/gotel/pkg/config"
+	"github.com/GetSimpl/gotel/pkg/queue"
+	"github.com/GetSimpl/gotel/pkg/tlsconfig"
+)
+
+// newHTTPClient builds the HTTP client used by the OTLP exporter. Requests pass through,
+// from the outside in: payload size logging to log in debug mode, the optional on-disk
+// queue, headers and credentials, then TLS.
+// Headers and TLS sit below the queue so replayed batches use fresh credentials and certificates.
+func newHTTPClient(cfg *config.Config, timeout time.Duration, log *slog.Logger, queueOpts queue.Options) (*http.Client, *queue.Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	var transport http.RoundTripper = base
+
+	if tlsOpts := tlsOptions(cfg); tlsOpts.Enabled() {
+		tlsTransport, err := tlsconfig.NewTransport(base, tlsOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		transport = tlsTransport
+	}
+
+	transport = auth.NewTransport(transport, auth.Options{
+		Headers:       cfg.Headers,
+		TokenProvider: tokenProvider(cfg),
+		Header:        cfg.AuthHeader,
+		Scheme:        cfg.AuthScheme,
+	})
+
+	var exportQueue *queue.Transport
+	if cfg.QueueDir != "" {
+		var err error
+		exportQueue, err = queue.NewTransport(transport, queueOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open export queue: %w", err)
+		}
+		transport = exportQueue
+	}
+
+	if cfg.EnableDebug && log != nil {
+		transport = &payloadSizeTransport{base: transport, logger: log}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, exportQueue, nil
+}
+
+// tlsOptions returns the exporter TLS settings of cfg
+func tlsOptions(cfg *config.Config) tlsconfig.Options {
+	return tlsconfig.Options{
+		CAFile:             cfg.TLSCAFile,
+		CertFile:           cfg.TLSCertFile,
+		KeyFile:            cfg.TLSKeyFile,
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+}
+
+// tokenProvider returns the credential source configured in cfg, or nil when exports are unauthenticated
+func tokenProvider(cfg *config.Config) auth.TokenProvider {
+	switch {
+	case cfg.TokenProvider != nil:
+		return cfg.TokenProvider
+	case cfg.AuthTokenFile != "":
+		return auth.NewFileTokenProvider(cfg.AuthTokenFile)
+	case cfg.OAuth2TokenURL != "":
+		return auth.NewClientCredentialsProvider(auth.ClientCredentialsConfig{
+			TokenURL:     cfg.OAuth2TokenURL,
+			ClientID:     cfg.OAuth2ClientID,
+			ClientSecret: cfg.OAuth2ClientSecret,
+			Scopes:       cfg.OAuth2Scopes,
+		})
+	default:
+		return nil
+	}
+}
+
+// payloadSizeTransport logs the size of every export payload, before and after compression
+type payloadSizeTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+}
+
+// RoundTrip logs the payload sizes of req and sends it unchanged
+func (t *payloadSizeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export payload: %w", err)
+	}
+
+	encoding := req.Header.Get("Content-Encoding")
+	if encoding == "gzip" {
+		uncompressed, err := gzipUncompressedSize(body)
+		if err != nil {
+			t.logger.Info("exporting OTLP payload", "encoding", encoding, "bytes", len(body), "err", err.Error())
+		} else {
+			t.logger.Info("exporting OTLP payload", "encoding", encoding, "bytes", len(body), "uncompressedBytes", uncompressed,
+				"ratio", fmt.Sprintf("%.2f", float64(len(body))/float64(max(uncompressed, 1))))
+		}
+	} else {
+		t.logger.Info("exporting OTLP payload", "encoding", "none", "bytes", len(body), "uncompressedBytes", len(body))
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return t.base.RoundTrip(clone)
+}
+
+// gzipUncompressedSize returns the size of a gzip payload once decompressed
+func gzipUncompressedSize(body []byte) (int64, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read gzip payload: %w", err)
+	}
+	defer reader.Close()
+
+	return io.Copy(io.Discard, reader)
+}
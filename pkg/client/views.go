@@ -0,0 +1,76 @@
+package client
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/GetSimpl/gotel/pkg/config"
+)
+
+// newViews converts the configured views into SDK views
+func newViews(views []config.View) []sdkmetric.View {
+	result := make([]sdkmetric.View, 0, len(views))
+	for _, view := range views {
+		result = append(result, newView(view))
+	}
+
+	return result
+}
+
+// newView returns an SDK view applying v to the instruments matching its name.
+// Buckets without an aggregation only apply to histograms, other instruments keep theirs.
+func newView(v config.View) sdkmetric.View {
+	match := sdkmetric.NewView(sdkmetric.Instrument{Name: v.Name}, sdkmetric.Stream{Name: v.Rename})
+
+	return func(inst sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		stream, ok := match(inst)
+		if !ok {
+			return stream, false
+		}
+
+		switch {
+		case v.Drop:
+			stream.Aggregation = sdkmetric.AggregationDrop{}
+		case v.Aggregation != "":
+			stream.Aggregation = newAggregation(v.Aggregation, v.Buckets, inst.Kind)
+		case len(v.Buckets) > 0 && inst.Kind == sdkmetric.InstrumentKindHistogram:
+			stream.Aggregation = sdkmetric.AggregationExplicitBucketHistogram{Boundaries: v.Buckets}
+		}
+
+		if len(v.AttributeKeys) > 0 {
+			keys := make([]attribute.Key, len(v.AttributeKeys))
+			for i, key := range v.AttributeKeys {
+				keys[i] = attribute.Key(key)
+			}
+			stream.AttributeFilter = attribute.NewAllowKeysFilter(keys...)
+		}
+
+		return stream, true
+	}
+}
+
+// newAggregation returns the SDK aggregation named by one of the config.Aggregation constants.
+// Explicit bucket histograms without buckets keep the boundaries of a histogram instrument,
+// other instruments get the SDK default boundaries.
+func newAggregation(name string, buckets []float64, kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	switch name {
+	case config.AggregationDrop:
+		return sdkmetric.AggregationDrop{}
+	case config.AggregationSum:
+		return sdkmetric.AggregationSum{}
+	case config.AggregationLastValue:
+		return sdkmetric.AggregationLastValue{}
+	case config.AggregationExplicitBucketHistogram:
+		if len(buckets) > 0 {
+			return sdkmetric.AggregationExplicitBucketHistogram{Boundaries: buckets}
+		}
+		if kind == sdkmetric.InstrumentKindHistogram {
+			return nil
+		}
+		return sdkmetric.DefaultAggregationSelector(sdkmetric.InstrumentKindHistogram)
+	case config.AggregationExponentialHistogram:
+		return sdkmetric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+// Package clock abstracts the time source of the export pipeline, so tests and
+// simulations can control the expiry and replay backoff of queued batches and the
+// export interval without waiting for the wall clock.
+package clock
+
+import "time"
+
+// Clock tells the time and waits for durations
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After sends the current time on the returned channel once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// System is the Clock of the time package
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// OrSystem returns c, or System when c is nil
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
@@ -3,44 +3,277 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+
+	"github.com/GetSimpl/gotel/pkg/auth"
+	"github.com/GetSimpl/gotel/pkg/meta"
+)
+
+// Default exporter timings, also used by the client when a Config leaves them unset
+const (
+	DefaultExportTimeout        = 30 * time.Second
+	DefaultRetryInitialInterval = 5 * time.Second
+	DefaultRetryMaxInterval     = 30 * time.Second
+	DefaultRetryMaxElapsedTime  = time.Minute
+	DefaultFlushTimeout         = 30 * time.Second
+	DefaultShutdownTimeout      = 5 * time.Second
+	DefaultDetectorTimeout      = 2 * time.Second
+)
+
+// Supported compressions of OTLP export payloads
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Temporality preferences of the exporter, as in OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE
+const (
+	// TemporalityCumulative exports every instrument cumulatively, e.g. for Prometheus
+	TemporalityCumulative = "cumulative"
+	// TemporalityDelta exports counters and histograms as deltas, up-down counters stay cumulative
+	TemporalityDelta = "delta"
+	// TemporalityLowMemory is delta for synchronous counters and histograms only
+	TemporalityLowMemory = "lowmemory"
+)
+
+// Validation modes of metric names and units, see metrics.ValidationMode
+const (
+	MetricValidationOff    = "off"
+	MetricValidationWarn   = "warn"
+	MetricValidationStrict = "strict"
+)
+
+// Aggregations a View can select, named as in the OpenTelemetry configuration file format
+const (
+	AggregationDefault                 = "default"
+	AggregationDrop                    = "drop"
+	AggregationSum                     = "sum"
+	AggregationLastValue               = "last_value"
+	AggregationExplicitBucketHistogram = "explicit_bucket_histogram"
+	AggregationExponentialHistogram    = "base2_exponential_bucket_histogram"
 )
 
 // Config holds all configuration for GoTel
 type Config struct {
-	// OTEL settings
+	// OTEL settings. OtelEndpoint is the full URL metrics are posted to.
 	OtelEndpoint string `mapstructure:"otel_endpoint"`
+	Protocol     string `mapstructure:"otel_exporter_otlp_metrics_protocol"`
+
+	// SDKDisabled turns every metric into a no-op, nothing is exported
+	SDKDisabled bool `mapstructure:"otel_sdk_disabled"`
+
+	// RegisterGlobal installs the client's meter provider and error handler as the otel
+	// globals, for third-party instrumentation, and its logger as logger.Logger. Only one
+	// client in a process should set it.
+	RegisterGlobal bool `mapstructure:"otel_register_global"`
 
 	// Application identification
 	ServiceName    string `mapstructure:"otel_service_name"`
 	ServiceVersion string `mapstructure:"otel_service_version"`
 	Environment    string `mapstructure:"env"`
 
+	// Additional resource attributes, e.g. from OTEL_RESOURCE_ATTRIBUTES
+	ResourceAttributes map[string]string `mapstructure:"otel_resource_attributes"`
+
+	// LegacyDefaultLabels also puts service.name, environment and container.id on every
+	// datapoint, as before they moved to the resource, for dashboards filtering on them
+	LegacyDefaultLabels bool `mapstructure:"otel_legacy_default_labels"`
+
+	// Datapoint labels. DefaultLabels selects the built-in labels put on every datapoint, see
+	// the DefaultLabel constants, LegacyDefaultLabels selects all of them. StaticLabels are
+	// put on every datapoint as well, LabelCollision decides when a caller sets the same key.
+	DefaultLabels  []string          `mapstructure:"otel_default_labels"`
+	StaticLabels   map[string]string `mapstructure:"otel_static_labels"`
+	LabelCollision string            `mapstructure:"otel_label_collision"`
+	// LabelAllow keeps only the label keys matching one of its patterns, LabelDeny then drops
+	// the keys matching one of its own. * and ? are wildcards.
+	LabelAllow []string `mapstructure:"otel_label_allow"`
+	LabelDeny  []string `mapstructure:"otel_label_deny"`
+	// LabelRedactions rewrite label values after filtering, only set from a config file
+	LabelRedactions []LabelRedaction `mapstructure:"-"`
+
+	// ResourceDetectors names the meta detectors adding host, process or platform attributes
+	// to the resource, e.g. "host" or "process". Attributes set explicitly take precedence.
+	ResourceDetectors []string `mapstructure:"otel_resource_detectors"`
+	// ResourceDetectorTimeout bounds each detector, they run concurrently
+	ResourceDetectorTimeout time.Duration `mapstructure:"otel_resource_detector_timeout"`
+
 	// Timing settings
-	SendInterval int `mapstructure:"otel_send_interval"`
+	SendInterval    int           `mapstructure:"otel_send_interval"`
+	ExportTimeout   time.Duration `mapstructure:"otel_export_timeout"`
+	FlushTimeout    time.Duration `mapstructure:"otel_flush_timeout"`
+	ShutdownTimeout time.Duration `mapstructure:"otel_shutdown_timeout"`
+
+	// Exporter retry with exponential backoff
+	RetryEnabled         bool          `mapstructure:"otel_retry_enabled"`
+	RetryInitialInterval time.Duration `mapstructure:"otel_retry_initial_interval"`
+	RetryMaxInterval     time.Duration `mapstructure:"otel_retry_max_interval"`
+	RetryMaxElapsedTime  time.Duration `mapstructure:"otel_retry_max_elapsed_time"`
+
+	// Exporter payload compression, CompressionNone or CompressionGzip
+	Compression string `mapstructure:"otel_exporter_otlp_compression"`
+
+	// Temporality of the exported data points, one of the Temporality constants
+	Temporality string `mapstructure:"otel_exporter_otlp_metrics_temporality_preference"`
+
+	// Exporter headers, e.g. an API key for a SaaS backend
+	Headers map[string]string `mapstructure:"otel_exporter_otlp_headers"`
+
+	// Exporter credentials refreshed on every export. TokenProvider takes precedence over
+	// a token file, which takes precedence over the OAuth2 client-credentials settings.
+	TokenProvider      auth.TokenProvider `mapstructure:"-"`
+	AuthHeader         string             `mapstructure:"otel_auth_header"`
+	AuthScheme         string             `mapstructure:"otel_auth_scheme"`
+	AuthTokenFile      string             `mapstructure:"otel_auth_token_file"`
+	OAuth2TokenURL     string             `mapstructure:"otel_oauth2_token_url"`
+	OAuth2ClientID     string             `mapstructure:"otel_oauth2_client_id"`
+	OAuth2ClientSecret string             `mapstructure:"otel_oauth2_client_secret"`
+	OAuth2Scopes       []string           `mapstructure:"otel_oauth2_scopes"`
+
+	// Exporter TLS, certificate files are reloaded when they change on disk
+	TLSCAFile             string `mapstructure:"otel_exporter_otlp_certificate"`
+	TLSCertFile           string `mapstructure:"otel_exporter_otlp_client_certificate"`
+	TLSKeyFile            string `mapstructure:"otel_exporter_otlp_client_key"`
+	TLSServerName         string `mapstructure:"otel_tls_server_name"`
+	TLSInsecureSkipVerify bool   `mapstructure:"otel_tls_insecure_skip_verify"`
 
 	// Debug and logging
 	EnableDebug bool `mapstructure:"otel_debug"`
+
+	// Persistent export queue, disabled when QueueDir is empty
+	QueueDir      string        `mapstructure:"otel_queue_dir"`
+	QueueMaxBytes int64         `mapstructure:"otel_queue_max_bytes"`
+	QueueMaxAge   time.Duration `mapstructure:"otel_queue_max_age"`
+
+	// Views customize the exported streams of matching instruments, only set from a config file
+	Views []View `mapstructure:"-"`
+
+	// Limits on what the metrics registry accepts. Zero buckets means the registry default.
+	MaxHistogramBuckets int `mapstructure:"otel_limit_histogram_buckets"`
+
+	// MetricValidation checks metric names and units against the OTEL naming rules and
+	// metric names used with conflicting kinds, units or buckets: off, warn logs and keeps
+	// the metric, strict drops it
+	MetricValidation string `mapstructure:"otel_metric_validation"`
+}
+
+// View customizes the stream exported for the instruments matching Name. An instrument
+// matched by several views is exported once per view.
+type View struct {
+	// Name matches instrument names, * and ? are wildcards
+	Name string `mapstructure:"name"`
+	// Rename exports the instrument under another name, only for a Name without wildcards
+	Rename string `mapstructure:"rename"`
+	// Drop stops the matching instruments from being exported, like AggregationDrop
+	Drop bool `mapstructure:"drop"`
+	// Aggregation replaces the default aggregation of the instrument kind, see the Aggregation constants
+	Aggregation string `mapstructure:"aggregation"`
+	// Buckets overrides the histogram bucket boundaries
+	Buckets []float64 `mapstructure:"buckets"`
+	// AttributeKeys is an allowlist of attributes to keep, all are kept when empty
+	AttributeKeys []string `mapstructure:"attribute_keys"`
+}
+
+// Validate checks that the settings of the view can be applied together
+func (v View) Validate() error {
+	if v.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if v.Rename != "" && strings.ContainsAny(v.Name, "*?") {
+		return fmt.Errorf("rename needs a name without wildcards, got %q", v.Name)
+	}
+
+	switch v.Aggregation {
+	case "", AggregationDefault, AggregationDrop, AggregationSum, AggregationLastValue,
+		AggregationExplicitBucketHistogram, AggregationExponentialHistogram:
+	default:
+		return fmt.Errorf("unknown aggregation %q", v.Aggregation)
+	}
+	if v.Drop && v.Aggregation != "" && v.Aggregation != AggregationDrop {
+		return fmt.Errorf("drop can't be combined with aggregation %q", v.Aggregation)
+	}
+
+	if len(v.Buckets) > 0 {
+		if v.Aggregation != "" && v.Aggregation != AggregationExplicitBucketHistogram {
+			return fmt.Errorf("buckets need aggregation %q, got %q", AggregationExplicitBucketHistogram, v.Aggregation)
+		}
+		for i := 1; i < len(v.Buckets); i++ {
+			if v.Buckets[i] <= v.Buckets[i-1] {
+				return fmt.Errorf("buckets must be in increasing order")
+			}
+		}
+	}
+
+	return nil
 }
 
 // Default returns a new Config with default values
 func Default() *Config {
 	return &Config{
-		OtelEndpoint:   "http://localhost:4318",
-		ServiceName:    "gotel-app",
-		ServiceVersion: "1.0.0",
-		Environment:    "local",
-		SendInterval:   30,
-		EnableDebug:    false,
+		OtelEndpoint:            "http://localhost:4318",
+		Protocol:                ProtocolHTTPProtobuf,
+		ServiceName:             "gotel-app",
+		ServiceVersion:          "1.0.0",
+		Environment:             "local",
+		SendInterval:            30,
+		ExportTimeout:           DefaultExportTimeout,
+		FlushTimeout:            DefaultFlushTimeout,
+		ShutdownTimeout:         DefaultShutdownTimeout,
+		ResourceDetectorTimeout: DefaultDetectorTimeout,
+		RetryEnabled:            true,
+		RetryInitialInterval:    DefaultRetryInitialInterval,
+		RetryMaxInterval:        DefaultRetryMaxInterval,
+		RetryMaxElapsedTime:     DefaultRetryMaxElapsedTime,
+		Compression:             CompressionNone,
+		Temporality:             TemporalityCumulative,
+		AuthHeader:              auth.DefaultHeader,
+		AuthScheme:              auth.DefaultScheme,
+		EnableDebug:             false,
+		QueueMaxBytes:           64 << 20, // 64 MiB
+		QueueMaxAge:             time.Hour,
+		MaxHistogramBuckets:     20,
+		MetricValidation:        MetricValidationWarn,
+	}
+}
+
+// LoadConfig loads configuration from environment variables using Viper. With WithSearchPaths
+// the first config file found is read first and environment variables override its values.
+func LoadConfig(opts ...LoadOption) (*Config, error) {
+	var options loadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	cfg := Default()
+
+	if path, ok := findConfigFile(options.searchPaths); ok {
+		if err := applyConfigFile(cfg, path); err != nil {
+			return nil, err
+		}
 	}
+
+	return loadEnvironment(cfg)
 }
 
-// LoadConfig loads configuration from environment variables using Viper
-func LoadConfig() (*Config, error) {
+// LoadConfigFromFile loads a YAML, TOML or JSON config file, with environment variables overriding its values
+func LoadConfigFromFile(path string) (*Config, error) {
 	cfg := Default()
 
+	if err := applyConfigFile(cfg, path); err != nil {
+		return nil, err
+	}
+
+	return loadEnvironment(cfg)
+}
+
+// loadEnvironment overrides cfg with environment variables and validates the result
+func loadEnvironment(cfg *Config) (*Config, error) {
 	v := viper.New()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
@@ -51,10 +284,14 @@ func LoadConfig() (*Config, error) {
 	setupEnvironmentBindings(v)
 
 	// Bind configuration struct to Viper
-	if err := v.Unmarshal(cfg); err != nil {
+	if err := v.Unmarshal(cfg, viper.DecodeHook(decodeHook())); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := applyStandardEnvironment(cfg); err != nil {
+		return nil, fmt.Errorf("failed to apply OTEL environment: %w", err)
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -66,31 +303,120 @@ func LoadConfig() (*Config, error) {
 // setDefaults sets default values in Viper
 func setDefaults(v *viper.Viper, cfg *Config) {
 	v.SetDefault("otel_endpoint", cfg.OtelEndpoint)
+	v.SetDefault("otel_exporter_otlp_metrics_protocol", cfg.Protocol)
+	v.SetDefault("otel_sdk_disabled", cfg.SDKDisabled)
+	v.SetDefault("otel_register_global", cfg.RegisterGlobal)
+	v.SetDefault("otel_resource_attributes", cfg.ResourceAttributes)
+	v.SetDefault("otel_resource_detectors", cfg.ResourceDetectors)
+	v.SetDefault("otel_legacy_default_labels", cfg.LegacyDefaultLabels)
+	v.SetDefault("otel_default_labels", cfg.DefaultLabels)
+	v.SetDefault("otel_static_labels", cfg.StaticLabels)
+	v.SetDefault("otel_label_collision", cfg.LabelCollision)
+	v.SetDefault("otel_label_allow", cfg.LabelAllow)
+	v.SetDefault("otel_label_deny", cfg.LabelDeny)
+	v.SetDefault("otel_resource_detector_timeout", cfg.ResourceDetectorTimeout)
 	v.SetDefault("otel_debug", cfg.EnableDebug)
 	v.SetDefault("env", cfg.Environment)
 	v.SetDefault("otel_send_interval", cfg.SendInterval)
+	v.SetDefault("otel_export_timeout", cfg.ExportTimeout)
+	v.SetDefault("otel_flush_timeout", cfg.FlushTimeout)
+	v.SetDefault("otel_shutdown_timeout", cfg.ShutdownTimeout)
+	v.SetDefault("otel_retry_enabled", cfg.RetryEnabled)
+	v.SetDefault("otel_retry_initial_interval", cfg.RetryInitialInterval)
+	v.SetDefault("otel_retry_max_interval", cfg.RetryMaxInterval)
+	v.SetDefault("otel_retry_max_elapsed_time", cfg.RetryMaxElapsedTime)
 	v.SetDefault("otel_service_name", cfg.ServiceName)
 	v.SetDefault("otel_service_version", cfg.ServiceVersion)
+	v.SetDefault("otel_exporter_otlp_compression", cfg.Compression)
+	v.SetDefault("otel_exporter_otlp_metrics_temporality_preference", cfg.Temporality)
+	v.SetDefault("otel_exporter_otlp_headers", cfg.Headers)
+	v.SetDefault("otel_auth_header", cfg.AuthHeader)
+	v.SetDefault("otel_auth_scheme", cfg.AuthScheme)
+	v.SetDefault("otel_auth_token_file", cfg.AuthTokenFile)
+	v.SetDefault("otel_oauth2_token_url", cfg.OAuth2TokenURL)
+	v.SetDefault("otel_oauth2_client_id", cfg.OAuth2ClientID)
+	v.SetDefault("otel_oauth2_client_secret", cfg.OAuth2ClientSecret)
+	v.SetDefault("otel_oauth2_scopes", cfg.OAuth2Scopes)
+	v.SetDefault("otel_exporter_otlp_certificate", cfg.TLSCAFile)
+	v.SetDefault("otel_exporter_otlp_client_certificate", cfg.TLSCertFile)
+	v.SetDefault("otel_exporter_otlp_client_key", cfg.TLSKeyFile)
+	v.SetDefault("otel_tls_server_name", cfg.TLSServerName)
+	v.SetDefault("otel_tls_insecure_skip_verify", cfg.TLSInsecureSkipVerify)
+	v.SetDefault("otel_queue_dir", cfg.QueueDir)
+	v.SetDefault("otel_queue_max_bytes", cfg.QueueMaxBytes)
+	v.SetDefault("otel_queue_max_age", cfg.QueueMaxAge)
+	v.SetDefault("otel_limit_histogram_buckets", cfg.MaxHistogramBuckets)
+	v.SetDefault("otel_metric_validation", cfg.MetricValidation)
 }
 
 // setupEnvironmentBindings configures environment variable bindings
 func setupEnvironmentBindings(v *viper.Viper) {
 	envBindings := map[string]string{
-		"otel_endpoint":        "OTEL_ENDPOINT",
-		"otel_debug":           "OTEL_DEBUG",
-		"env":                  "ENV",
-		"otel_send_interval":   "OTEL_SEND_INTERVAL",
-		"otel_service_name":    "OTEL_SERVICE_NAME",
-		"otel_service_version": "OTEL_SERVICE_VERSION",
+		"otel_endpoint":                         "OTEL_ENDPOINT",
+		"otel_debug":                            "OTEL_DEBUG",
+		"otel_register_global":                  "OTEL_REGISTER_GLOBAL",
+		"otel_resource_detectors":               "OTEL_RESOURCE_DETECTORS",
+		"otel_legacy_default_labels":            "OTEL_LEGACY_DEFAULT_LABELS",
+		"otel_default_labels":                   "OTEL_DEFAULT_LABELS",
+		"otel_static_labels":                    "OTEL_STATIC_LABELS",
+		"otel_label_collision":                  "OTEL_LABEL_COLLISION",
+		"otel_label_allow":                      "OTEL_LABEL_ALLOW",
+		"otel_label_deny":                       "OTEL_LABEL_DENY",
+		"otel_resource_detector_timeout":        "OTEL_RESOURCE_DETECTOR_TIMEOUT",
+		"env":                                   "ENV",
+		"otel_send_interval":                    "OTEL_SEND_INTERVAL",
+		"otel_service_name":                     "OTEL_SERVICE_NAME",
+		"otel_service_version":                  "OTEL_SERVICE_VERSION",
+		"otel_export_timeout":                   "OTEL_EXPORT_TIMEOUT",
+		"otel_flush_timeout":                    "OTEL_FLUSH_TIMEOUT",
+		"otel_shutdown_timeout":                 "OTEL_SHUTDOWN_TIMEOUT",
+		"otel_retry_enabled":                    "OTEL_RETRY_ENABLED",
+		"otel_retry_initial_interval":           "OTEL_RETRY_INITIAL_INTERVAL",
+		"otel_retry_max_interval":               "OTEL_RETRY_MAX_INTERVAL",
+		"otel_retry_max_elapsed_time":           "OTEL_RETRY_MAX_ELAPSED_TIME",
+		"otel_exporter_otlp_compression":        "OTEL_EXPORTER_OTLP_COMPRESSION",
+		"otel_exporter_otlp_headers":            "OTEL_EXPORTER_OTLP_HEADERS",
+		"otel_auth_header":                      "OTEL_AUTH_HEADER",
+		"otel_auth_scheme":                      "OTEL_AUTH_SCHEME",
+		"otel_auth_token_file":                  "OTEL_AUTH_TOKEN_FILE",
+		"otel_oauth2_token_url":                 "OTEL_OAUTH2_TOKEN_URL",
+		"otel_oauth2_client_id":                 "OTEL_OAUTH2_CLIENT_ID",
+		"otel_oauth2_client_secret":             "OTEL_OAUTH2_CLIENT_SECRET",
+		"otel_oauth2_scopes":                    "OTEL_OAUTH2_SCOPES",
+		"otel_exporter_otlp_certificate":        "OTEL_EXPORTER_OTLP_CERTIFICATE",
+		"otel_exporter_otlp_client_certificate": "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE",
+		"otel_exporter_otlp_client_key":         "OTEL_EXPORTER_OTLP_CLIENT_KEY",
+		"otel_tls_server_name":                  "OTEL_TLS_SERVER_NAME",
+		"otel_tls_insecure_skip_verify":         "OTEL_TLS_INSECURE_SKIP_VERIFY",
+		"otel_queue_dir":                        "OTEL_QUEUE_DIR",
+		"otel_queue_max_bytes":                  "OTEL_QUEUE_MAX_BYTES",
+		"otel_queue_max_age":                    "OTEL_QUEUE_MAX_AGE",
+		"otel_limit_histogram_buckets":          "OTEL_LIMIT_HISTOGRAM_BUCKETS",
+		"otel_metric_validation":                "OTEL_METRIC_VALIDATION",
 	}
 
 	for key, env := range envBindings {
 		v.BindEnv(key, env)
 	}
+
+	// Standard OTEL variables, the first one set wins
+	standardBindings := map[string][]string{
+		"otel_exporter_otlp_metrics_protocol":               {"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"},
+		"otel_resource_attributes":                          {"OTEL_RESOURCE_ATTRIBUTES"},
+		"otel_sdk_disabled":                                 {"OTEL_SDK_DISABLED"},
+		"otel_exporter_otlp_metrics_temporality_preference": {"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE"},
+	}
+
+	for key, envs := range standardBindings {
+		v.BindEnv(append([]string{key}, envs...)...)
+	}
 }
 
 // Validate validates the configuration
 func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is required")
+	}
 	if cfg.OtelEndpoint == "" {
 		return fmt.Errorf("otel_endpoint is required")
 	}
@@ -100,9 +426,134 @@ func (cfg *Config) Validate() error {
 	if cfg.ServiceVersion == "" {
 		return fmt.Errorf("service_version is required")
 	}
+	switch cfg.Protocol {
+	case "", ProtocolHTTPProtobuf:
+	default:
+		return fmt.Errorf("protocol %q is not supported, only %q", cfg.Protocol, ProtocolHTTPProtobuf)
+	}
 	if cfg.SendInterval <= 0 {
 		return fmt.Errorf("send_interval must be positive")
 	}
+	// Zero timeouts mean the DefaultExportTimeout, DefaultFlushTimeout and DefaultShutdownTimeout
+	if cfg.ExportTimeout < 0 {
+		return fmt.Errorf("export_timeout must not be negative")
+	}
+	if cfg.FlushTimeout < 0 {
+		return fmt.Errorf("flush_timeout must not be negative")
+	}
+	if cfg.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown_timeout must not be negative")
+	}
+	if cfg.RetryEnabled {
+		if cfg.RetryInitialInterval <= 0 {
+			return fmt.Errorf("retry_initial_interval must be positive")
+		}
+		if cfg.RetryMaxInterval < cfg.RetryInitialInterval {
+			return fmt.Errorf("retry_max_interval must not be less than retry_initial_interval")
+		}
+		if cfg.RetryMaxElapsedTime <= 0 {
+			return fmt.Errorf("retry_max_elapsed_time must be positive")
+		}
+	}
+	switch cfg.Compression {
+	case "", CompressionNone, CompressionGzip:
+	default:
+		return fmt.Errorf("compression must be %q or %q, got %q", CompressionNone, CompressionGzip, cfg.Compression)
+	}
+	if cfg.ResourceDetectorTimeout < 0 {
+		return fmt.Errorf("resource_detector_timeout must not be negative")
+	}
+	for _, name := range cfg.ResourceDetectors {
+		if !meta.IsDetector(name) {
+			return fmt.Errorf("unknown resource detector %q", name)
+		}
+	}
+	switch cfg.Temporality {
+	case "", TemporalityCumulative, TemporalityDelta, TemporalityLowMemory:
+	default:
+		return fmt.Errorf("temporality must be %q, %q or %q, got %q", TemporalityCumulative, TemporalityDelta, TemporalityLowMemory, cfg.Temporality)
+	}
+	if cfg.OAuth2TokenURL != "" && cfg.OAuth2ClientID == "" {
+		return fmt.Errorf("oauth2_client_id is required when oauth2_token_url is set")
+	}
+	if cfg.OAuth2TokenURL != "" && cfg.AuthTokenFile != "" {
+		return fmt.Errorf("auth_token_file and oauth2_token_url are mutually exclusive")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls client certificate and key must be set together")
+	}
+	if cfg.QueueDir != "" {
+		if cfg.QueueMaxBytes <= 0 {
+			return fmt.Errorf("queue_max_bytes must be positive when queue_dir is set")
+		}
+		if cfg.QueueMaxAge <= 0 {
+			return fmt.Errorf("queue_max_age must be positive when queue_dir is set")
+		}
+	}
+	if cfg.MaxHistogramBuckets < 0 {
+		return fmt.Errorf("limit_histogram_buckets must not be negative")
+	}
+	switch cfg.MetricValidation {
+	case "", MetricValidationOff, MetricValidationWarn, MetricValidationStrict:
+	default:
+		return fmt.Errorf("metric_validation must be %q, %q or %q, got %q", MetricValidationOff, MetricValidationWarn, MetricValidationStrict, cfg.MetricValidation)
+	}
+	for i, view := range cfg.Views {
+		if err := view.Validate(); err != nil {
+			return fmt.Errorf("views[%d]: %w", i, err)
+		}
+	}
+	if err := cfg.validateLabels(); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// decodeHook extends Viper's default decode hooks so map settings can be given
+// in the OTEL "key1=value1,key2=value2" environment variable format
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToMapHookFunc(),
+	)
+}
+
+// stringToMapHookFunc decodes a key-value list string into a map[string]string
+func stringToMapHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]string{}) {
+			return data, nil
+		}
+
+		return ParseKeyValueList(data.(string))
+	}
+}
+
+// ParseKeyValueList parses the W3C Baggage style list used by OTEL_EXPORTER_OTLP_HEADERS
+// and OTEL_RESOURCE_ATTRIBUTES. Keys and values are trimmed and values are URL-decoded.
+func ParseKeyValueList(s string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid key-value pair %q", pair)
+		}
+
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for key %q: %w", key, err)
+		}
+
+		result[key] = decoded
+	}
+
+	return result, nil
+}
@@ -0,0 +1,481 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{
+			name:   "default config is valid",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "zero timeouts use the defaults",
+			modify: func(cfg *Config) {
+				cfg.ExportTimeout = 0
+				cfg.FlushTimeout = 0
+				cfg.ShutdownTimeout = 0
+			},
+		},
+		{
+			name:    "negative export timeout",
+			modify:  func(cfg *Config) { cfg.ExportTimeout = -time.Second },
+			wantErr: "export_timeout",
+		},
+		{
+			name:    "negative flush timeout",
+			modify:  func(cfg *Config) { cfg.FlushTimeout = -time.Second },
+			wantErr: "flush_timeout",
+		},
+		{
+			name:    "negative shutdown timeout",
+			modify:  func(cfg *Config) { cfg.ShutdownTimeout = -time.Second },
+			wantErr: "shutdown_timeout",
+		},
+		{
+			name:    "retry with zero initial interval",
+			modify:  func(cfg *Config) { cfg.RetryInitialInterval = 0 },
+			wantErr: "retry_initial_interval",
+		},
+		{
+			name: "retry max interval below initial interval",
+			modify: func(cfg *Config) {
+				cfg.RetryInitialInterval = 10 * time.Second
+				cfg.RetryMaxInterval = time.Second
+			},
+			wantErr: "retry_max_interval",
+		},
+		{
+			name:    "retry with zero max elapsed time",
+			modify:  func(cfg *Config) { cfg.RetryMaxElapsedTime = 0 },
+			wantErr: "retry_max_elapsed_time",
+		},
+		{
+			name: "retry settings ignored when retry is disabled",
+			modify: func(cfg *Config) {
+				cfg.RetryEnabled = false
+				cfg.RetryInitialInterval = 0
+				cfg.RetryMaxElapsedTime = 0
+			},
+		},
+		{
+			name:    "unsupported compression",
+			modify:  func(cfg *Config) { cfg.Compression = "zstd" },
+			wantErr: "compression",
+		},
+		{
+			name:   "empty compression means none",
+			modify: func(cfg *Config) { cfg.Compression = "" },
+		},
+		{
+			name:    "tls client certificate without key",
+			modify:  func(cfg *Config) { cfg.TLSCertFile = "/etc/gotel/client.pem" },
+			wantErr: "tls client certificate",
+		},
+		{
+			name: "queue without size limit",
+			modify: func(cfg *Config) {
+				cfg.QueueDir = "/tmp/gotel-queue"
+				cfg.QueueMaxBytes = 0
+			},
+			wantErr: "queue_max_bytes",
+		},
+		{
+			name: "valid views",
+			modify: func(cfg *Config) {
+				cfg.Views = []View{
+					{Name: "legacy_requests", Rename: "http.server.requests.total"},
+					{Name: "db.*", Aggregation: AggregationExplicitBucketHistogram, Buckets: []float64{1, 5, 25}},
+					{Name: "queue.depth", Aggregation: AggregationLastValue, AttributeKeys: []string{"queue"}},
+					{Name: "debug.*", Drop: true, Aggregation: AggregationDrop},
+				}
+			},
+		},
+		{
+			name:    "view without name",
+			modify:  func(cfg *Config) { cfg.Views = []View{{Drop: true}} },
+			wantErr: "views[0]: name is required",
+		},
+		{
+			name:    "view renaming a wildcard",
+			modify:  func(cfg *Config) { cfg.Views = []View{{Name: "http.*", Rename: "http"}} },
+			wantErr: "rename needs a name without wildcards",
+		},
+		{
+			name:    "view with unknown aggregation",
+			modify:  func(cfg *Config) { cfg.Views = []View{{Name: "http.*", Aggregation: "avg"}} },
+			wantErr: "unknown aggregation",
+		},
+		{
+			name:    "view dropping with another aggregation",
+			modify:  func(cfg *Config) { cfg.Views = []View{{Name: "http.*", Drop: true, Aggregation: AggregationSum}} },
+			wantErr: "drop can't be combined",
+		},
+		{
+			name: "view with buckets and another aggregation",
+			modify: func(cfg *Config) {
+				cfg.Views = []View{{Name: "http.*", Aggregation: AggregationSum, Buckets: []float64{1}}}
+			},
+			wantErr: "buckets need aggregation",
+		},
+		{
+			name:    "view with unsorted buckets",
+			modify:  func(cfg *Config) { cfg.Views = []View{{Name: "http.*", Buckets: []float64{5, 1}}} },
+			wantErr: "increasing order",
+		},
+		{
+			name:    "unknown default label",
+			modify:  func(cfg *Config) { cfg.DefaultLabels = []string{"host.name"} },
+			wantErr: "default label",
+		},
+		{
+			name:    "unknown label collision policy",
+			modify:  func(cfg *Config) { cfg.LabelCollision = "merge" },
+			wantErr: "label_collision",
+		},
+		{
+			name:    "unknown metric validation mode",
+			modify:  func(cfg *Config) { cfg.MetricValidation = "error" },
+			wantErr: "metric_validation",
+		},
+		{
+			name:    "malformed label pattern",
+			modify:  func(cfg *Config) { cfg.LabelDeny = []string{"user.[id"} },
+			wantErr: "invalid label pattern",
+		},
+		{
+			name:    "redaction matching nothing",
+			modify:  func(cfg *Config) { cfg.LabelRedactions = []LabelRedaction{{Replacement: "x"}} },
+			wantErr: "key or value is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Timeouts(t *testing.T) {
+	t.Setenv("OTEL_EXPORT_TIMEOUT", "10s")
+	t.Setenv("OTEL_FLUSH_TIMEOUT", "15s")
+	t.Setenv("OTEL_SHUTDOWN_TIMEOUT", "2s")
+	t.Setenv("OTEL_RETRY_ENABLED", "true")
+	t.Setenv("OTEL_RETRY_INITIAL_INTERVAL", "500ms")
+	t.Setenv("OTEL_RETRY_MAX_INTERVAL", "5s")
+	t.Setenv("OTEL_RETRY_MAX_ELAPSED_TIME", "2m")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "gzip")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, 10*time.Second, cfg.ExportTimeout)
+	assert.Equal(t, 15*time.Second, cfg.FlushTimeout)
+	assert.Equal(t, 2*time.Second, cfg.ShutdownTimeout)
+	assert.True(t, cfg.RetryEnabled)
+	assert.Equal(t, 500*time.Millisecond, cfg.RetryInitialInterval)
+	assert.Equal(t, 5*time.Second, cfg.RetryMaxInterval)
+	assert.Equal(t, 2*time.Minute, cfg.RetryMaxElapsedTime)
+	assert.Equal(t, CompressionGzip, cfg.Compression)
+}
+
+func TestLoadConfig_InvalidTimeout(t *testing.T) {
+	t.Setenv("OTEL_SHUTDOWN_TIMEOUT", "-1s")
+
+	_, err := LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shutdown_timeout")
+}
+
+func TestParseKeyValueList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: map[string]string{},
+		},
+		{
+			name:     "single pair",
+			input:    "api-key=secret",
+			expected: map[string]string{"api-key": "secret"},
+		},
+		{
+			name:     "multiple pairs with whitespace",
+			input:    " api-key = secret , tenant=team-a,",
+			expected: map[string]string{"api-key": "secret", "tenant": "team-a"},
+		},
+		{
+			name:     "url encoded value",
+			input:    "Authorization=Bearer%20abc%3D%3D",
+			expected: map[string]string{"Authorization": "Bearer abc=="},
+		},
+		{
+			name:     "value containing equals sign",
+			input:    "token=abc==",
+			expected: map[string]string{"token": "abc=="},
+		},
+		{
+			name:    "missing separator",
+			input:   "api-key",
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			input:   "=value",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseKeyValueList(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestLoadConfig_Headers(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=secret,x-tenant=team%20a")
+	t.Setenv("OTEL_OAUTH2_TOKEN_URL", "https://auth.example.com/token")
+	t.Setenv("OTEL_OAUTH2_CLIENT_ID", "gotel")
+	t.Setenv("OTEL_OAUTH2_SCOPES", "metrics.write,metrics.read")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"x-api-key": "secret", "x-tenant": "team a"}, cfg.Headers)
+	assert.Equal(t, "https://auth.example.com/token", cfg.OAuth2TokenURL)
+	assert.Equal(t, []string{"metrics.write", "metrics.read"}, cfg.OAuth2Scopes)
+	assert.Equal(t, "Authorization", cfg.AuthHeader)
+	assert.Equal(t, "Bearer", cfg.AuthScheme)
+}
+
+func TestLoadConfig_InvalidHeaders(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "not-a-pair")
+
+	_, err := LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_StandardEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "gotel variables",
+			env:  map[string]string{"OTEL_ENDPOINT": "http://collector:4318/v1/metrics", "OTEL_SEND_INTERVAL": "15"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "http://collector:4318/v1/metrics", cfg.OtelEndpoint)
+				assert.Equal(t, 15, cfg.SendInterval)
+			},
+		},
+		{
+			name: "base endpoint gets the metrics path",
+			env: map[string]string{
+				"OTEL_ENDPOINT":               "http://legacy:4318",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "http://collector:4318/v1/metrics", cfg.OtelEndpoint)
+			},
+		},
+		{
+			name: "metrics endpoint is used as is",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":         "http://collector:4318",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "https://metrics.example.com/otlp",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "https://metrics.example.com/otlp", cfg.OtelEndpoint)
+			},
+		},
+		{
+			name: "export interval in milliseconds overrides send interval",
+			env:  map[string]string{"OTEL_SEND_INTERVAL": "15", "OTEL_METRIC_EXPORT_INTERVAL": "60000"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 60, cfg.SendInterval)
+			},
+		},
+		{
+			name: "sub-second export interval rounds up",
+			env:  map[string]string{"OTEL_METRIC_EXPORT_INTERVAL": "500"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 1, cfg.SendInterval)
+			},
+		},
+		{
+			name: "resource attributes fill in the service identity",
+			env: map[string]string{
+				"OTEL_RESOURCE_ATTRIBUTES": "service.name=checkout,service.version=2.1.0,deployment.environment=prod,team=payments",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "checkout", cfg.ServiceName)
+				assert.Equal(t, "2.1.0", cfg.ServiceVersion)
+				assert.Equal(t, "prod", cfg.Environment)
+				assert.Equal(t, "payments", cfg.ResourceAttributes["team"])
+			},
+		},
+		{
+			name: "service variables take precedence over resource attributes",
+			env: map[string]string{
+				"OTEL_RESOURCE_ATTRIBUTES": "service.name=checkout,deployment.environment=prod",
+				"OTEL_SERVICE_NAME":        "orders",
+				"ENV":                      "staging",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "orders", cfg.ServiceName)
+				assert.Equal(t, "staging", cfg.Environment)
+			},
+		},
+		{
+			name: "metrics protocol takes precedence",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_PROTOCOL":         "grpc",
+				"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/protobuf",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, ProtocolHTTPProtobuf, cfg.Protocol)
+			},
+		},
+		{
+			name: "metric validation",
+			env:  map[string]string{"OTEL_METRIC_VALIDATION": "strict"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, MetricValidationStrict, cfg.MetricValidation)
+			},
+		},
+		{
+			name: "sdk disabled",
+			env:  map[string]string{"OTEL_SDK_DISABLED": "true"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.SDKDisabled)
+			},
+		},
+		{
+			name: "datapoint labels",
+			env: map[string]string{
+				"OTEL_DEFAULT_LABELS":  "service.name,container.id",
+				"OTEL_STATIC_LABELS":   "team=payments,tier=1",
+				"OTEL_LABEL_COLLISION": "error",
+				"OTEL_LABEL_ALLOW":     "http.*,team",
+				"OTEL_LABEL_DENY":      "http.url",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{DefaultLabelServiceName, DefaultLabelContainerID}, cfg.DefaultLabels)
+				assert.Equal(t, map[string]string{"team": "payments", "tier": "1"}, cfg.StaticLabels)
+				assert.Equal(t, LabelCollisionError, cfg.LabelCollision)
+				assert.Equal(t, []string{"http.*", "team"}, cfg.LabelAllow)
+				assert.Equal(t, []string{"http.url"}, cfg.LabelDeny)
+			},
+		},
+		{
+			name: "resource detectors",
+			env:  map[string]string{"OTEL_RESOURCE_DETECTORS": "host,process", "OTEL_RESOURCE_DETECTOR_TIMEOUT": "500ms"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{"host", "process"}, cfg.ResourceDetectors)
+				assert.Equal(t, 500*time.Millisecond, cfg.ResourceDetectorTimeout)
+			},
+		},
+		{
+			name: "temporality preference is case insensitive",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "Delta"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, TemporalityDelta, cfg.Temporality)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := LoadConfig()
+			require.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfig_InvalidStandardEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unsupported protocol",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+			wantErr: "protocol",
+		},
+		{
+			name:    "unsupported metrics protocol",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/json"},
+			wantErr: "protocol",
+		},
+		{
+			name:    "export interval not a number",
+			env:     map[string]string{"OTEL_METRIC_EXPORT_INTERVAL": "30s"},
+			wantErr: "OTEL_METRIC_EXPORT_INTERVAL",
+		},
+		{
+			name:    "malformed resource attributes",
+			env:     map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "team"},
+			wantErr: "team",
+		},
+		{
+			name:    "unknown resource detector",
+			env:     map[string]string{"OTEL_RESOURCE_DETECTORS": "host,mainframe"},
+			wantErr: "mainframe",
+		},
+		{
+			name:    "unknown temporality preference",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "sometimes"},
+			wantErr: "temporality",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
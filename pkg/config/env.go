@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProtocolHTTPProtobuf is the only OTLP protocol the exporter supports
+const ProtocolHTTPProtobuf = "http/protobuf"
+
+// metricsPath is appended to OTEL_EXPORTER_OTLP_ENDPOINT as the OTLP specification requires
+const metricsPath = "/v1/metrics"
+
+// Resource attribute keys that fill in the service identity when the gotel variables are unset
+const (
+	serviceNameAttribute           = "service.name"
+	serviceVersionAttribute        = "service.version"
+	deploymentEnvironmentAttribute = "deployment.environment"
+)
+
+// applyStandardEnvironment applies the OTEL specification variables that need more than a
+// plain binding. Precedence, from highest to lowest:
+//
+//	endpoint:        OTEL_EXPORTER_OTLP_METRICS_ENDPOINT, OTEL_EXPORTER_OTLP_ENDPOINT + /v1/metrics, OTEL_ENDPOINT
+//	interval:        OTEL_METRIC_EXPORT_INTERVAL (ms), OTEL_SEND_INTERVAL (s)
+//	service name:    OTEL_SERVICE_NAME, service.name in OTEL_RESOURCE_ATTRIBUTES
+//	service version: OTEL_SERVICE_VERSION, service.version in OTEL_RESOURCE_ATTRIBUTES
+//	environment:     ENV, deployment.environment in OTEL_RESOURCE_ATTRIBUTES
+func applyStandardEnvironment(cfg *Config) error {
+	if endpoint, ok := lookupEnv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"); ok {
+		cfg.OtelEndpoint = endpoint
+	} else if endpoint, ok := lookupEnv("OTEL_EXPORTER_OTLP_ENDPOINT"); ok {
+		cfg.OtelEndpoint = strings.TrimRight(endpoint, "/") + metricsPath
+	}
+
+	if interval, ok := lookupEnv("OTEL_METRIC_EXPORT_INTERVAL"); ok {
+		seconds, err := exportIntervalSeconds(interval)
+		if err != nil {
+			return err
+		}
+		cfg.SendInterval = seconds
+	}
+
+	// The specification treats the temporality preference as case insensitive
+	cfg.Temporality = strings.ToLower(cfg.Temporality)
+
+	if _, ok := lookupEnv("OTEL_SERVICE_NAME"); !ok {
+		if name, ok := cfg.ResourceAttributes[serviceNameAttribute]; ok {
+			cfg.ServiceName = name
+		}
+	}
+	if _, ok := lookupEnv("OTEL_SERVICE_VERSION"); !ok {
+		if version, ok := cfg.ResourceAttributes[serviceVersionAttribute]; ok {
+			cfg.ServiceVersion = version
+		}
+	}
+	if _, ok := lookupEnv("ENV"); !ok {
+		if environment, ok := cfg.ResourceAttributes[deploymentEnvironmentAttribute]; ok {
+			cfg.Environment = environment
+		}
+	}
+
+	return nil
+}
+
+// exportIntervalSeconds converts OTEL_METRIC_EXPORT_INTERVAL milliseconds into the whole
+// seconds of SendInterval, rounding up so short intervals do not become zero
+func exportIntervalSeconds(interval string) (int, error) {
+	ms, err := strconv.Atoi(interval)
+	if err != nil || ms <= 0 {
+		return 0, fmt.Errorf("OTEL_METRIC_EXPORT_INTERVAL must be a positive number of milliseconds, got %q", interval)
+	}
+
+	return (ms + 999) / 1000, nil
+}
+
+// lookupEnv returns the value of a non-empty environment variable
+func lookupEnv(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// ConfigFileNames are the file names looked for in every search path, in order
+var ConfigFileNames = []string{"gotel.yaml", "gotel.yml", "gotel.toml", "gotel.json"}
+
+// LoadOption configures LoadConfig
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	searchPaths []string
+}
+
+// WithSearchPaths makes LoadConfig read the first config file found in dirs.
+// Without a config file in any of them only the environment is used.
+func WithSearchPaths(dirs ...string) LoadOption {
+	return func(o *loadOptions) {
+		o.searchPaths = append(o.searchPaths, dirs...)
+	}
+}
+
+// findConfigFile returns the first config file found in dirs
+func findConfigFile(dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		for _, name := range ConfigFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// applyConfigFile overrides cfg with the values set in the config file at path
+func applyConfigFile(cfg *Config, path string) error {
+	// Attribute and header keys contain dots, which must not be read as nested keys
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	file := newFileConfig(cfg)
+	if err := v.Unmarshal(file, viper.DecodeHook(decodeHook()), func(dc *mapstructure.DecoderConfig) {
+		dc.ErrorUnused = true
+	}); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	file.applyTo(cfg)
+
+	return nil
+}
+
+// fileConfig is the structured config file format, see gotel.schema.json
+type fileConfig struct {
+	Exporter         exporterSection         `mapstructure:"exporter"`
+	Resource         resourceSection         `mapstructure:"resource"`
+	Views            []View                  `mapstructure:"views"`
+	Labels           labelsSection           `mapstructure:"labels"`
+	Limits           limitsSection           `mapstructure:"limits"`
+	Instrumentations instrumentationsSection `mapstructure:"instrumentations"`
+	Disabled         bool                    `mapstructure:"disabled"`
+	RegisterGlobal   bool                    `mapstructure:"register_global"`
+	Debug            bool                    `mapstructure:"debug"`
+	FlushTimeout     time.Duration           `mapstructure:"flush_timeout"`
+	ShutdownTimeout  time.Duration           `mapstructure:"shutdown_timeout"`
+}
+
+type exporterSection struct {
+	Endpoint     string            `mapstructure:"endpoint"`
+	Protocol     string            `mapstructure:"protocol"`
+	Compression  string            `mapstructure:"compression"`
+	Temporality  string            `mapstructure:"temporality_preference"`
+	SendInterval int               `mapstructure:"send_interval"`
+	Timeout      time.Duration     `mapstructure:"timeout"`
+	Headers      map[string]string `mapstructure:"headers"`
+	Retry        retrySection      `mapstructure:"retry"`
+	Auth         authSection       `mapstructure:"auth"`
+	TLS          tlsSection        `mapstructure:"tls"`
+	Queue        queueSection      `mapstructure:"queue"`
+}
+
+type retrySection struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	MaxInterval     time.Duration `mapstructure:"max_interval"`
+	MaxElapsedTime  time.Duration `mapstructure:"max_elapsed_time"`
+}
+
+type authSection struct {
+	Header    string        `mapstructure:"header"`
+	Scheme    string        `mapstructure:"scheme"`
+	TokenFile string        `mapstructure:"token_file"`
+	OAuth2    oauth2Section `mapstructure:"oauth2"`
+}
+
+type oauth2Section struct {
+	TokenURL     string   `mapstructure:"token_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+type tlsSection struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type queueSection struct {
+	Dir      string        `mapstructure:"dir"`
+	MaxBytes int64         `mapstructure:"max_bytes"`
+	MaxAge   time.Duration `mapstructure:"max_age"`
+}
+
+type resourceSection struct {
+	ServiceName     string            `mapstructure:"service_name"`
+	ServiceVersion  string            `mapstructure:"service_version"`
+	Environment     string            `mapstructure:"environment"`
+	Attributes      map[string]string `mapstructure:"attributes"`
+	Detectors       []string          `mapstructure:"detectors"`
+	DetectorTimeout time.Duration     `mapstructure:"detector_timeout"`
+	LegacyLabels    bool              `mapstructure:"legacy_default_labels"`
+}
+
+type labelsSection struct {
+	Defaults   []string          `mapstructure:"defaults"`
+	Static     map[string]string `mapstructure:"static"`
+	Collision  string            `mapstructure:"collision"`
+	Allow      []string          `mapstructure:"allow"`
+	Deny       []string          `mapstructure:"deny"`
+	Redactions []LabelRedaction  `mapstructure:"redact"`
+}
+
+type limitsSection struct {
+	HistogramBuckets int    `mapstructure:"histogram_buckets"`
+	Validation       string `mapstructure:"validation"`
+}
+
+// instrumentationsSection has no settings yet, so only an empty section is accepted
+type instrumentationsSection struct{}
+
+// newFileConfig returns the file representation of cfg, so keys missing from a file keep their value
+func newFileConfig(cfg *Config) *fileConfig {
+	return &fileConfig{
+		Exporter: exporterSection{
+			Endpoint:     cfg.OtelEndpoint,
+			Protocol:     cfg.Protocol,
+			Compression:  cfg.Compression,
+			Temporality:  cfg.Temporality,
+			SendInterval: cfg.SendInterval,
+			Timeout:      cfg.ExportTimeout,
+			Headers:      cfg.Headers,
+			Retry: retrySection{
+				Enabled:         cfg.RetryEnabled,
+				InitialInterval: cfg.RetryInitialInterval,
+				MaxInterval:     cfg.RetryMaxInterval,
+				MaxElapsedTime:  cfg.RetryMaxElapsedTime,
+			},
+			Auth: authSection{
+				Header:    cfg.AuthHeader,
+				Scheme:    cfg.AuthScheme,
+				TokenFile: cfg.AuthTokenFile,
+				OAuth2: oauth2Section{
+					TokenURL:     cfg.OAuth2TokenURL,
+					ClientID:     cfg.OAuth2ClientID,
+					ClientSecret: cfg.OAuth2ClientSecret,
+					Scopes:       cfg.OAuth2Scopes,
+				},
+			},
+			TLS: tlsSection{
+				CAFile:             cfg.TLSCAFile,
+				CertFile:           cfg.TLSCertFile,
+				KeyFile:            cfg.TLSKeyFile,
+				ServerName:         cfg.TLSServerName,
+				InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+			},
+			Queue: queueSection{
+				Dir:      cfg.QueueDir,
+				MaxBytes: cfg.QueueMaxBytes,
+				MaxAge:   cfg.QueueMaxAge,
+			},
+		},
+		Resource: resourceSection{
+			ServiceName:     cfg.ServiceName,
+			ServiceVersion:  cfg.ServiceVersion,
+			Environment:     cfg.Environment,
+			Attributes:      cfg.ResourceAttributes,
+			Detectors:       cfg.ResourceDetectors,
+			DetectorTimeout: cfg.ResourceDetectorTimeout,
+			LegacyLabels:    cfg.LegacyDefaultLabels,
+		},
+		Views: cfg.Views,
+		Labels: labelsSection{
+			Defaults:   cfg.DefaultLabels,
+			Static:     cfg.StaticLabels,
+			Collision:  cfg.LabelCollision,
+			Allow:      cfg.LabelAllow,
+			Deny:       cfg.LabelDeny,
+			Redactions: cfg.LabelRedactions,
+		},
+		Limits: limitsSection{
+			HistogramBuckets: cfg.MaxHistogramBuckets,
+			Validation:       cfg.MetricValidation,
+		},
+		Disabled:        cfg.SDKDisabled,
+		RegisterGlobal:  cfg.RegisterGlobal,
+		Debug:           cfg.EnableDebug,
+		FlushTimeout:    cfg.FlushTimeout,
+		ShutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// applyTo copies the file values into cfg
+func (f *fileConfig) applyTo(cfg *Config) {
+	cfg.OtelEndpoint = f.Exporter.Endpoint
+	cfg.Protocol = f.Exporter.Protocol
+	cfg.Compression = f.Exporter.Compression
+	cfg.Temporality = f.Exporter.Temporality
+	cfg.SendInterval = f.Exporter.SendInterval
+	cfg.ExportTimeout = f.Exporter.Timeout
+	cfg.Headers = f.Exporter.Headers
+
+	cfg.RetryEnabled = f.Exporter.Retry.Enabled
+	cfg.RetryInitialInterval = f.Exporter.Retry.InitialInterval
+	cfg.RetryMaxInterval = f.Exporter.Retry.MaxInterval
+	cfg.RetryMaxElapsedTime = f.Exporter.Retry.MaxElapsedTime
+
+	cfg.AuthHeader = f.Exporter.Auth.Header
+	cfg.AuthScheme = f.Exporter.Auth.Scheme
+	cfg.AuthTokenFile = f.Exporter.Auth.TokenFile
+	cfg.OAuth2TokenURL = f.Exporter.Auth.OAuth2.TokenURL
+	cfg.OAuth2ClientID = f.Exporter.Auth.OAuth2.ClientID
+	cfg.OAuth2ClientSecret = f.Exporter.Auth.OAuth2.ClientSecret
+	cfg.OAuth2Scopes = f.Exporter.Auth.OAuth2.Scopes
+
+	cfg.TLSCAFile = f.Exporter.TLS.CAFile
+	cfg.TLSCertFile = f.Exporter.TLS.CertFile
+	cfg.TLSKeyFile = f.Exporter.TLS.KeyFile
+	cfg.TLSServerName = f.Exporter.TLS.ServerName
+	cfg.TLSInsecureSkipVerify = f.Exporter.TLS.InsecureSkipVerify
+
+	cfg.QueueDir = f.Exporter.Queue.Dir
+	cfg.QueueMaxBytes = f.Exporter.Queue.MaxBytes
+	cfg.QueueMaxAge = f.Exporter.Queue.MaxAge
+
+	cfg.ServiceName = f.Resource.ServiceName
+	cfg.ServiceVersion = f.Resource.ServiceVersion
+	cfg.Environment = f.Resource.Environment
+	cfg.ResourceAttributes = f.Resource.Attributes
+	cfg.ResourceDetectors = f.Resource.Detectors
+	cfg.ResourceDetectorTimeout = f.Resource.DetectorTimeout
+	cfg.LegacyDefaultLabels = f.Resource.LegacyLabels
+
+	cfg.Views = f.Views
+
+	cfg.DefaultLabels = f.Labels.Defaults
+	cfg.StaticLabels = f.Labels.Static
+	cfg.LabelCollision = f.Labels.Collision
+	cfg.LabelAllow = f.Labels.Allow
+	cfg.LabelDeny = f.Labels.Deny
+	cfg.LabelRedactions = f.Labels.Redactions
+	cfg.MaxHistogramBuckets = f.Limits.HistogramBuckets
+	cfg.MetricValidation = f.Limits.Validation
+
+	cfg.SDKDisabled = f.Disabled
+	cfg.RegisterGlobal = f.RegisterGlobal
+	cfg.EnableDebug = f.Debug
+	cfg.FlushTimeout = f.FlushTimeout
+	cfg.ShutdownTimeout = f.ShutdownTimeout
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlConfig = `
+exporter:
+  endpoint: https://collector.example.com/v1/metrics
+  compression: gzip
+  send_interval: 10
+  timeout: 5s
+  headers:
+    X-API-Key: secret
+  retry:
+    enabled: false
+  tls:
+    ca_file: /etc/gotel/ca.pem
+  queue:
+    dir: /var/lib/gotel
+    max_age: 30m
+resource:
+  service_name: checkout
+  service_version: 2.1.0
+  environment: prod
+  attributes:
+    team.name: payments
+    cloud.region: eu-west-1
+views:
+  - name: http.server.*
+    attribute_keys: [route, status]
+  - name: debug.*
+    drop: true
+  - name: legacy_requests
+    rename: http.server.requests.total
+    aggregation: sum
+labels:
+  defaults: [service.name]
+  static:
+    team: payments
+  collision: caller
+  deny: [user.*]
+  redact:
+    - key: card\.number
+limits:
+  validation: strict
+instrumentations: {}
+shutdown_timeout: 10s
+`
+
+const tomlConfig = `
+flush_timeout = "20s"
+
+[exporter]
+endpoint = "http://collector:4318/v1/metrics"
+send_interval = 15
+
+[resource]
+service_name = "orders"
+
+[resource.attributes]
+"team.name" = "fulfilment"
+
+[[views]]
+name = "db.query.duration"
+buckets = [1.0, 5.0, 25.0]
+`
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		cfg, err := LoadConfigFromFile(writeConfigFile(t, t.TempDir(), "gotel.yaml", yamlConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://collector.example.com/v1/metrics", cfg.OtelEndpoint)
+		assert.Equal(t, CompressionGzip, cfg.Compression)
+		assert.Equal(t, 10, cfg.SendInterval)
+		assert.Equal(t, 5*time.Second, cfg.ExportTimeout)
+		assert.Equal(t, map[string]string{"x-api-key": "secret"}, cfg.Headers)
+		assert.False(t, cfg.RetryEnabled)
+		assert.Equal(t, "/etc/gotel/ca.pem", cfg.TLSCAFile)
+		assert.Equal(t, "/var/lib/gotel", cfg.QueueDir)
+		assert.Equal(t, 30*time.Minute, cfg.QueueMaxAge)
+		assert.Equal(t, int64(64<<20), cfg.QueueMaxBytes, "keys missing from the file keep their default")
+		assert.Equal(t, "checkout", cfg.ServiceName)
+		assert.Equal(t, "2.1.0", cfg.ServiceVersion)
+		assert.Equal(t, "prod", cfg.Environment)
+		assert.Equal(t, map[string]string{"team.name": "payments", "cloud.region": "eu-west-1"}, cfg.ResourceAttributes)
+		assert.Equal(t, []View{
+			{Name: "http.server.*", AttributeKeys: []string{"route", "status"}},
+			{Name: "debug.*", Drop: true},
+			{Name: "legacy_requests", Rename: "http.server.requests.total", Aggregation: AggregationSum},
+		}, cfg.Views)
+		assert.Equal(t, []string{DefaultLabelServiceName}, cfg.DefaultLabels)
+		assert.Equal(t, map[string]string{"team": "payments"}, cfg.StaticLabels)
+		assert.Equal(t, LabelCollisionCaller, cfg.LabelCollision)
+		assert.Equal(t, []string{"user.*"}, cfg.LabelDeny)
+		assert.Equal(t, []LabelRedaction{{Key: `card\.number`}}, cfg.LabelRedactions)
+		assert.Equal(t, MetricValidationStrict, cfg.MetricValidation)
+		assert.Equal(t, 20, cfg.MaxHistogramBuckets)
+		assert.Equal(t, 10*time.Second, cfg.ShutdownTimeout)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		cfg, err := LoadConfigFromFile(writeConfigFile(t, t.TempDir(), "gotel.toml", tomlConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, "http://collector:4318/v1/metrics", cfg.OtelEndpoint)
+		assert.Equal(t, 15, cfg.SendInterval)
+		assert.Equal(t, 20*time.Second, cfg.FlushTimeout)
+		assert.Equal(t, "orders", cfg.ServiceName)
+		assert.Equal(t, map[string]string{"team.name": "fulfilment"}, cfg.ResourceAttributes)
+		assert.Equal(t, []View{{Name: "db.query.duration", Buckets: []float64{1, 5, 25}}}, cfg.Views)
+	})
+
+	t.Run("environment overrides file values", func(t *testing.T) {
+		t.Setenv("OTEL_SERVICE_NAME", "checkout-canary")
+		t.Setenv("OTEL_EXPORT_TIMEOUT", "2s")
+		t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "cloud.region=us-east-1")
+
+		cfg, err := LoadConfigFromFile(writeConfigFile(t, t.TempDir(), "gotel.yaml", yamlConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, "checkout-canary", cfg.ServiceName)
+		assert.Equal(t, 2*time.Second, cfg.ExportTimeout)
+		assert.Equal(t, "prod", cfg.Environment)
+		assert.Equal(t, map[string]string{"team.name": "payments", "cloud.region": "us-east-1"}, cfg.ResourceAttributes)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dir := t.TempDir()
+
+		tests := []struct {
+			name    string
+			path    string
+			wantErr string
+		}{
+			{
+				name:    "missing file",
+				path:    filepath.Join(dir, "missing.yaml"),
+				wantErr: "failed to read config file",
+			},
+			{
+				name:    "unknown key",
+				path:    writeConfigFile(t, dir, "typo.yaml", "exporter:\n  endpont: http://collector:4318\n"),
+				wantErr: "endpont",
+			},
+			{
+				name:    "instrumentation setting",
+				path:    writeConfigFile(t, dir, "instrumentations.yaml", "instrumentations:\n  runtime:\n    enabled: true\n"),
+				wantErr: "runtime",
+			},
+			{
+				name:    "invalid duration",
+				path:    writeConfigFile(t, dir, "duration.yaml", "exporter:\n  timeout: soon\n"),
+				wantErr: "failed to parse config file",
+			},
+			{
+				name:    "invalid values",
+				path:    writeConfigFile(t, dir, "invalid.yaml", "exporter:\n  compression: zstd\n"),
+				wantErr: "compression",
+			},
+			{
+				name:    "view without name",
+				path:    writeConfigFile(t, dir, "view.yaml", "views:\n  - drop: true\n"),
+				wantErr: "views[0]",
+			},
+			{
+				name:    "invalid redaction",
+				path:    writeConfigFile(t, dir, "redact.yaml", "labels:\n  redact:\n    - value: \"[0-9\"\n"),
+				wantErr: "label_redactions[0]",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := LoadConfigFromFile(tt.path)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+		}
+	})
+}
+
+func TestLoadConfig_SearchPaths(t *testing.T) {
+	empty := t.TempDir()
+	first := t.TempDir()
+	second := t.TempDir()
+
+	writeConfigFile(t, first, "gotel.toml", tomlConfig)
+	writeConfigFile(t, second, "gotel.yaml", yamlConfig)
+
+	cfg, err := LoadConfig(WithSearchPaths(empty, first, second))
+	require.NoError(t, err)
+	assert.Equal(t, "orders", cfg.ServiceName, "the first directory with a config file wins")
+
+	cfg, err = LoadConfig(WithSearchPaths(empty))
+	require.NoError(t, err)
+	assert.Equal(t, Default().ServiceName, cfg.ServiceName, "without a config file only the environment is used")
+}
+
+// TestSchemaMatchesFileFormat keeps gotel.schema.json in sync with the keys the loader accepts
+func TestSchemaMatchesFileFormat(t *testing.T) {
+	data, err := os.ReadFile("gotel.schema.json")
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	var compare func(path string, typ reflect.Type, node map[string]any)
+	compare = func(path string, typ reflect.Type, node map[string]any) {
+		for typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+			node, _ = node["items"].(map[string]any)
+		}
+		if typ.Kind() != reflect.Struct {
+			return
+		}
+
+		properties, _ := node["properties"].(map[string]any)
+		assert.Equal(t, false, node["additionalProperties"], "%s must not allow unknown keys", path)
+
+		var fileKeys, schemaKeys []string
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			key := field.Tag.Get("mapstructure")
+			fileKeys = append(fileKeys, key)
+
+			if child, ok := properties[key].(map[string]any); ok {
+				compare(path+"."+key, field.Type, child)
+			}
+		}
+		for key := range properties {
+			schemaKeys = append(schemaKeys, key)
+		}
+
+		sort.Strings(fileKeys)
+		sort.Strings(schemaKeys)
+		assert.Equal(t, fileKeys, schemaKeys, "keys of %s", strings.TrimPrefix(path, "."))
+	}
+
+	compare("", reflect.TypeOf(fileConfig{}), schema)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+)
+
+// Built-in labels DefaultLabels can put on every datapoint
+const (
+	DefaultLabelServiceName = "service.name"
+	DefaultLabelEnvironment = "environment"
+	DefaultLabelContainerID = "container.id"
+)
+
+// Policies for a caller label with the key of a default or static label
+const (
+	// LabelCollisionDefault overwrites the caller's value, as gotel always did
+	LabelCollisionDefault = "default"
+	// LabelCollisionCaller keeps the caller's value
+	LabelCollisionCaller = "caller"
+	// LabelCollisionError drops the measurement and logs an error
+	LabelCollisionError = "error"
+)
+
+// RedactedValue replaces redacted label values when a LabelRedaction has no Replacement
+const RedactedValue = "[REDACTED]"
+
+// LabelRedaction rewrites the label values matching Value, in the labels whose key matches Key.
+// Either can be left out: without Key all labels are checked, without Value the whole value
+// of the matching keys is replaced.
+type LabelRedaction struct {
+	// Key is a regular expression matched against label keys
+	Key string `mapstructure:"key"`
+	// Value is a regular expression, the matching parts of the value are replaced
+	Value string `mapstructure:"value"`
+	// Replacement may refer to submatches of Value as $1, RedactedValue when empty
+	Replacement string `mapstructure:"replacement"`
+}
+
+// Validate checks that the redaction matches something and its expressions compile
+func (r LabelRedaction) Validate() error {
+	if r.Key == "" && r.Value == "" {
+		return fmt.Errorf("key or value is required")
+	}
+	if _, err := regexp.Compile(r.Key); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	if _, err := regexp.Compile(r.Value); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	return nil
+}
+
+// validateLabels checks the datapoint label settings of cfg
+func (cfg *Config) validateLabels() error {
+	for _, name := range cfg.DefaultLabels {
+		if !slices.Contains([]string{DefaultLabelServiceName, DefaultLabelEnvironment, DefaultLabelContainerID}, name) {
+			return fmt.Errorf("default label must be %q, %q or %q, got %q", DefaultLabelServiceName, DefaultLabelEnvironment, DefaultLabelContainerID, name)
+		}
+	}
+	switch cfg.LabelCollision {
+	case "", LabelCollisionDefault, LabelCollisionCaller, LabelCollisionError:
+	default:
+		return fmt.Errorf("label_collision must be %q, %q or %q, got %q", LabelCollisionDefault, LabelCollisionCaller, LabelCollisionError, cfg.LabelCollision)
+	}
+	for _, pattern := range slices.Concat(cfg.LabelAllow, cfg.LabelDeny) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid label pattern %q: %w", pattern, err)
+		}
+	}
+	for i, redaction := range cfg.LabelRedactions {
+		if err := redaction.Validate(); err != nil {
+			return fmt.Errorf("label_redactions[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
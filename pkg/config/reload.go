@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startupSettings are the Config fields only read when a client is created. Changing them
+// needs a restart: they shape the resource, the meter provider or the metrics registry.
+var startupSettings = []string{
+	"Protocol",
+	"Temporality",
+	"SDKDisabled",
+	"RegisterGlobal",
+	"ServiceName",
+	"ServiceVersion",
+	"Environment",
+	"ResourceAttributes",
+	"ResourceDetectors",
+	"ResourceDetectorTimeout",
+	"LegacyDefaultLabels",
+	"Views",
+	"MaxHistogramBuckets",
+	"MetricValidation",
+}
+
+// ReloadFrom returns a copy of next that keeps the startup-only settings of cfg, together
+// with the names of the startup-only settings next tried to change
+func (cfg *Config) ReloadFrom(next *Config) (*Config, []string) {
+	reloaded := *next
+	current := reflect.ValueOf(cfg).Elem()
+	target := reflect.ValueOf(&reloaded).Elem()
+
+	var ignored []string
+	for _, name := range startupSettings {
+		if !reflect.DeepEqual(current.FieldByName(name).Interface(), target.FieldByName(name).Interface()) {
+			ignored = append(ignored, name)
+		}
+		target.FieldByName(name).Set(current.FieldByName(name))
+	}
+
+	return &reloaded, ignored
+}
+
+// WatchConfigFile calls onChange with the config loaded by LoadConfigFromFile, or the error
+// loading it, every time the file at path is written or replaced. Errors of the watch itself
+// are passed to onChange as well. The watch stops once ctx is done.
+func WatchConfigFile(ctx context.Context, path string, onChange func(*Config, error)) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to watch config file %s: %w", path, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file %s: %w", path, err)
+	}
+
+	// The directory is watched, since editors and Kubernetes ConfigMap updates replace the
+	// file and a watch on the file itself ends with the file it was added for
+	file := filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("failed to watch config file %s: %w", path, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		// A ConfigMap update only swaps the symlink the file resolves through
+		target, _ := filepath.EvalSymlinks(file)
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				current, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				if !written && (current == "" || current == target) {
+					continue
+				}
+				target = current
+
+				if ctx.Err() == nil {
+					onChange(LoadConfigFromFile(path))
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(nil, fmt.Errorf("failed to watch config file %s: %w", path, err))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
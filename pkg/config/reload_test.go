@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ReloadFrom(t *testing.T) {
+	current := Default()
+	current.ServiceName = "checkout"
+
+	next := Default()
+	next.ServiceName = "orders"
+	next.OtelEndpoint = "http://collector:4318/v1/metrics"
+	next.SendInterval = 5
+	next.EnableDebug = true
+	next.Views = []View{{Name: "debug.*", Drop: true}}
+
+	reloaded, ignored := current.ReloadFrom(next)
+
+	assert.Equal(t, "http://collector:4318/v1/metrics", reloaded.OtelEndpoint)
+	assert.Equal(t, 5, reloaded.SendInterval)
+	assert.True(t, reloaded.EnableDebug)
+	assert.Equal(t, "checkout", reloaded.ServiceName)
+	assert.Nil(t, reloaded.Views)
+	assert.Equal(t, []string{"ServiceName", "Views"}, ignored)
+	assert.Equal(t, "orders", next.ServiceName, "next is not modified")
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "gotel.yaml", "exporter:\n  send_interval: 10\n")
+
+	type result struct {
+		cfg *Config
+		err error
+	}
+	changes := make(chan result, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, WatchConfigFile(ctx, path, func(cfg *Config, err error) {
+		changes <- result{cfg, err}
+	}))
+	// A write can be reported more than once, e.g. for truncating and then writing the file
+	waitFor := func(done func(result) bool) result {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case r := <-changes:
+				if done(r) {
+					return r
+				}
+			case <-timeout:
+				t.Fatal("config change not reported")
+				return result{}
+			}
+		}
+	}
+
+	writeConfigFile(t, dir, "gotel.yaml", "exporter:\n  send_interval: 20\n")
+	r := waitFor(func(r result) bool { return r.err == nil && r.cfg.SendInterval == 20 })
+	assert.Equal(t, 20, r.cfg.SendInterval)
+
+	writeConfigFile(t, dir, "gotel.yaml", "exporter:\n  compression: zstd\n")
+	r = waitFor(func(r result) bool { return r.err != nil })
+	assert.Contains(t, r.err.Error(), "compression")
+
+	// Editors save by writing another file and renaming it over the watched one
+	replacement := writeConfigFile(t, dir, "gotel.yaml.tmp", "exporter:\n  send_interval: 30\n")
+	require.NoError(t, os.Rename(replacement, path))
+	r = waitFor(func(r result) bool { return r.err == nil && r.cfg.SendInterval == 30 })
+	assert.Equal(t, 30, r.cfg.SendInterval)
+
+	t.Run("missing file", func(t *testing.T) {
+		err := WatchConfigFile(ctx, filepath.Join(dir, "missing.yaml"), func(*Config, error) {})
+		assert.Error(t, err)
+	})
+
+	t.Run("stops with the context", func(t *testing.T) {
+		cancel()
+		// Let the watch goroutine see the cancellation before the file changes again
+		time.Sleep(100 * time.Millisecond)
+		for len(changes) > 0 {
+			<-changes
+		}
+
+		writeConfigFile(t, dir, "gotel.yaml", "exporter:\n  send_interval: 40\n")
+
+		select {
+		case r := <-changes:
+			t.Fatalf("change reported after the context was done: %+v", r)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
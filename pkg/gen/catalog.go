@@ -0,0 +1,132 @@
+// Package gen generates Go code with typed recorders for the metrics of a catalog file,
+// see cmd/gotel
+package gen
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+
+	"github.com/GetSimpl/gotel/pkg/metrics"
+)
+
+// Catalog is a metric catalog file
+type Catalog struct {
+	// Package is the package of the generated code
+	Package string   `mapstructure:"package"`
+	Metrics []Metric `mapstructure:"metrics"`
+}
+
+// Metric is a metric of a catalog. Labels are the only label keys it can be recorded with.
+type Metric struct {
+	Name        string    `mapstructure:"name"`
+	Kind        string    `mapstructure:"kind"`
+	Unit        string    `mapstructure:"unit"`
+	Description string    `mapstructure:"description"`
+	Labels      []string  `mapstructure:"labels"`
+	Buckets     []float64 `mapstructure:"buckets"`
+}
+
+// labelKeyPattern are the label keys that can be turned into a struct field
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_./-]*$`)
+
+// LoadCatalog reads the YAML, TOML or JSON catalog at path
+func LoadCatalog(path string) (*Catalog, error) {
+	// Label keys and metric names contain dots, which must not be read as nested keys
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read catalog %s: %w", path, err)
+	}
+
+	catalog := new(Catalog)
+	if err := v.Unmarshal(catalog, func(dc *mapstructure.DecoderConfig) {
+		dc.ErrorUnused = true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+	}
+
+	return catalog, nil
+}
+
+// Definition returns the metrics.Definition of m
+func (m Metric) Definition() metrics.Definition {
+	return metrics.Definition{
+		Name:        metrics.MetricName(m.Name),
+		Unit:        metrics.Unit(m.Unit),
+		Description: m.Description,
+		Kind:        metrics.Kind(m.Kind),
+		Buckets:     m.Buckets,
+	}
+}
+
+// Validate checks the catalog with the strict metric validation of the registry, and that
+// every metric and label key gives a distinct Go identifier
+func (c *Catalog) Validate() error {
+	if !token.IsIdentifier(c.Package) {
+		return fmt.Errorf("package must be a Go identifier, got %q", c.Package)
+	}
+	if len(c.Metrics) == 0 {
+		return fmt.Errorf("catalog has no metrics")
+	}
+
+	// Identifiers of the generated code besides the ones of the metrics
+	idents := map[string]string{
+		"Definitions": "the definitions",
+		"Recorder":    "the recorder",
+		"NewRecorder": "the recorder constructor",
+	}
+	declare := func(ident, owner string) error {
+		if other, ok := idents[ident]; ok {
+			return fmt.Errorf("generated identifier %s is used by %s as well", ident, other)
+		}
+		idents[ident] = owner
+		return nil
+	}
+
+	for i, m := range c.Metrics {
+		if err := m.validate(); err != nil {
+			return fmt.Errorf("metrics[%d]: %w", i, err)
+		}
+
+		for _, ident := range m.idents() {
+			if err := declare(ident, fmt.Sprintf("%q", m.Name)); err != nil {
+				return fmt.Errorf("metrics[%d]: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate checks the metric and its label keys
+func (m Metric) validate() error {
+	def := m.Definition()
+	if err := def.Name.Validate(); err != nil {
+		return err
+	}
+	if err := def.Unit.Validate(); err != nil {
+		return err
+	}
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	fields := make(map[string]string, len(m.Labels))
+	for _, key := range m.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("%q label key %q must start with a letter and contain only letters, digits, '_', '.', '/' and '-'", m.Name, key)
+		}
+		field := exportedName(key)
+		if other, ok := fields[field]; ok {
+			return fmt.Errorf("%q label keys %q and %q both give the field %s", m.Name, other, key, field)
+		}
+		fields[field] = key
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/GetSimpl/gotel/pkg/metrics"
+)
+
+// Generate returns the Go code of the catalog: a metrics.MetricName constant and a
+// definition per metric, and a Recorder with a typed method per metric and instrument
+// operation. Label keys become the fields of a labels struct, so recording a label the
+// catalog doesn't list is a compile error. source names the catalog in the header.
+func Generate(c *Catalog, source string) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+
+	data := fileData{Source: source, Package: c.Package}
+	for _, m := range c.Metrics {
+		data.Metrics = append(data.Metrics, newMetricData(m))
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+type fileData struct {
+	Source  string
+	Package string
+	Metrics []metricData
+}
+
+type metricData struct {
+	Metric
+	Ident   string
+	Comment string
+	Fields  []fieldData
+}
+
+type fieldData struct {
+	Name string
+	Key  string
+}
+
+func newMetricData(m Metric) metricData {
+	data := metricData{
+		Metric:  m,
+		Ident:   exportedName(m.Name),
+		Comment: strings.Join(strings.Fields(m.Description), " "),
+	}
+	for _, key := range m.Labels {
+		data.Fields = append(data.Fields, fieldData{Name: exportedName(key), Key: key})
+	}
+	return data
+}
+
+// idents returns the top-level and Recorder method identifiers generated for m
+func (m Metric) idents() []string {
+	ident := exportedName(m.Name)
+	idents := []string{ident}
+	if len(m.Labels) > 0 {
+		idents = append(idents, ident+"Labels")
+	}
+
+	switch metrics.Kind(m.Kind) {
+	case metrics.KindCounter:
+		idents = append(idents, "Increment"+ident, "Add"+ident)
+	case metrics.KindGauge:
+		idents = append(idents, "Set"+ident)
+	case metrics.KindHistogram:
+		idents = append(idents, "Record"+ident)
+	}
+
+	return idents
+}
+
+// exportedName turns a metric name or label key into an exported Go identifier,
+// e.g. http.server.request_count into HttpServerRequestCount
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
+
+// kindConst returns the metrics package constant of kind
+func kindConst(kind string) string {
+	switch metrics.Kind(kind) {
+	case metrics.KindCounter:
+		return "metrics.KindCounter"
+	case metrics.KindGauge:
+		return "metrics.KindGauge"
+	default:
+		return "metrics.KindHistogram"
+	}
+}
+
+// floats formats buckets as the elements of a []float64 literal
+func floats(buckets []float64) string {
+	elems := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		elems[i] = strconv.FormatFloat(bucket, 'g', -1, 64)
+	}
+	return strings.Join(elems, ", ")
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"kindConst": kindConst,
+	"floats":    floats,
+}).Parse(`// Code generated by gotel gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+
+	"github.com/GetSimpl/gotel"
+	"github.com/GetSimpl/gotel/pkg/metrics"
+)
+
+// Metric names of the catalog
+const (
+{{- range .Metrics}}
+	// {{.Ident}} is the {{.Kind}} {{.Name}}{{if .Comment}}: {{.Comment}}{{end}}
+	{{.Ident}} metrics.MetricName = {{printf "%q" .Name}}
+{{- end}}
+)
+
+// Definitions describe the metrics of the catalog, NewRecorder registers them
+var Definitions = []metrics.Definition{
+{{- range .Metrics}}
+	{Name: {{.Ident}}, Unit: {{printf "%q" .Unit}}, Description: {{printf "%q" .Description}}, Kind: {{kindConst .Kind}}{{if .Buckets}}, Buckets: []float64{ {{- floats .Buckets -}} }{{end}}},
+{{- end}}
+}
+
+// Recorder records the metrics of the catalog with the labels it lists
+type Recorder struct {
+	client gotel.Gotel
+}
+
+// NewRecorder registers Definitions with client and returns a Recorder using it
+func NewRecorder(client gotel.Gotel) (*Recorder, error) {
+	if err := client.Register(Definitions...); err != nil {
+		return nil, fmt.Errorf("failed to register metric definitions: %w", err)
+	}
+
+	return &Recorder{client: client}, nil
+}
+{{range .Metrics}}
+{{- $labels := "nil"}}{{$param := ""}}
+{{- if .Fields}}{{$labels = "labels.labels()"}}{{$param = printf "labels %sLabels" .Ident}}
+// {{.Ident}}Labels are the labels of {{.Name}}
+type {{.Ident}}Labels struct {
+{{- range .Fields}}
+	{{.Name}} string // {{.Key}}
+{{- end}}
+}
+
+func (l {{.Ident}}Labels) labels() map[string]string {
+	return map[string]string{
+{{- range .Fields}}
+		{{printf "%q" .Key}}: l.{{.Name}},
+{{- end}}
+	}
+}
+{{end}}
+{{- if eq .Kind "counter"}}
+// Increment{{.Ident}} increments {{.Name}} by 1
+func (r *Recorder) Increment{{.Ident}}({{$param}}) {
+	r.client.IncrementCounter({{.Ident}}, {{printf "%q" .Unit}}, {{$labels}})
+}
+
+// Add{{.Ident}} adds delta to {{.Name}}
+func (r *Recorder) Add{{.Ident}}(delta int64{{if $param}}, {{$param}}{{end}}) {
+	r.client.AddToCounter(delta, {{.Ident}}, {{printf "%q" .Unit}}, {{$labels}})
+}
+{{- else if eq .Kind "gauge"}}
+// Set{{.Ident}} sets {{.Name}} to value
+func (r *Recorder) Set{{.Ident}}(value float64{{if $param}}, {{$param}}{{end}}) {
+	r.client.SetGauge(value, {{.Ident}}, {{printf "%q" .Unit}}, {{$labels}})
+}
+{{- else}}
+// Record{{.Ident}} records value in {{.Name}}, with the buckets of its definition
+func (r *Recorder) Record{{.Ident}}(value float64{{if $param}}, {{$param}}{{end}}) {
+	r.client.RecordHistogram(value, {{.Ident}}, {{printf "%q" .Unit}}, nil, {{$labels}})
+}
+{{- end}}
+{{end}}`))
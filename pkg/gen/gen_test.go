@@ -0,0 +1,166 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/GetSimpl/gotel"
+	"github.com/GetSimpl/gotel/examples/checkoutmetrics"
+)
+
+const exampleDir = "../../examples/checkoutmetrics"
+
+// TestGenerate_Example keeps the committed example in sync with the generator, run
+// go generate ./examples/... after changing the template
+func TestGenerate_Example(t *testing.T) {
+	catalog, err := LoadCatalog(filepath.Join(exampleDir, "metrics.yaml"))
+	require.NoError(t, err)
+
+	src, err := Generate(catalog, "metrics.yaml")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile(filepath.Join(exampleDir, "metrics_gen.go"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(src))
+}
+
+func TestGenerate_Recorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	client, err := gotel.New(gotel.WithReader(reader))
+	require.NoError(t, err)
+	defer client.Close()
+
+	recorder, err := checkoutmetrics.NewRecorder(client)
+	require.NoError(t, err)
+
+	recorder.IncrementCheckoutOrdersPlaced(checkoutmetrics.CheckoutOrdersPlacedLabels{PaymentMethod: "card", Region: "eu"})
+	recorder.RecordCheckoutPaymentDuration(0.3, checkoutmetrics.CheckoutPaymentDurationLabels{PaymentMethod: "card", PaymentProvider: "acme"})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	found := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = m
+		}
+	}
+
+	orders := found["checkout.orders.placed"]
+	assert.Equal(t, "Orders accepted by checkout", orders.Description)
+	sum, ok := orders.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	method, _ := sum.DataPoints[0].Attributes.Value("payment.method")
+	assert.Equal(t, "card", method.AsString())
+
+	histogram, ok := found["checkout.payment.duration"].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+	assert.Equal(t, []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5}, histogram.DataPoints[0].Bounds)
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	valid := func() *Catalog {
+		return &Catalog{
+			Package: "metrics",
+			Metrics: []Metric{{Name: "orders.placed", Kind: "counter", Unit: "{order}", Labels: []string{"region"}}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *Catalog)
+		wantErr string
+	}{
+		{
+			name:   "valid catalog",
+			modify: func(c *Catalog) {},
+		},
+		{
+			name:    "missing package",
+			modify:  func(c *Catalog) { c.Package = "" },
+			wantErr: "package must be a Go identifier",
+		},
+		{
+			name:    "no metrics",
+			modify:  func(c *Catalog) { c.Metrics = nil },
+			wantErr: "no metrics",
+		},
+		{
+			name:    "invalid name",
+			modify:  func(c *Catalog) { c.Metrics[0].Name = "Orders Placed" },
+			wantErr: "metrics[0]: invalid metric name",
+		},
+		{
+			name:    "invalid unit",
+			modify:  func(c *Catalog) { c.Metrics[0].Unit = "orders" },
+			wantErr: "invalid metric unit",
+		},
+		{
+			name:    "unknown kind",
+			modify:  func(c *Catalog) { c.Metrics[0].Kind = "summary" },
+			wantErr: "kind must be",
+		},
+		{
+			name:    "label key without a field name",
+			modify:  func(c *Catalog) { c.Metrics[0].Labels = []string{"_region"} },
+			wantErr: `label key "_region"`,
+		},
+		{
+			name:    "label keys with the same field",
+			modify:  func(c *Catalog) { c.Metrics[0].Labels = []string{"payment.method", "payment_method"} },
+			wantErr: "both give the field PaymentMethod",
+		},
+		{
+			name: "metrics with the same identifier",
+			modify: func(c *Catalog) {
+				c.Metrics = append(c.Metrics, Metric{Name: "orders_placed", Kind: "gauge"})
+			},
+			wantErr: `metrics[1]: generated identifier OrdersPlaced is used by "orders.placed" as well`,
+		},
+		{
+			name: "metric with a reserved identifier",
+			modify: func(c *Catalog) {
+				c.Metrics = append(c.Metrics, Metric{Name: "recorder", Kind: "gauge"})
+			},
+			wantErr: "generated identifier Recorder is used by the recorder as well",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := valid()
+			tt.modify(catalog)
+
+			err := catalog.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadCatalog_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`package: metrics
+metrics:
+  - name: orders.placed
+    kind: counter
+    label: [region]
+`), 0o600))
+
+	_, err := LoadCatalog(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "label")
+}
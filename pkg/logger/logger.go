@@ -8,9 +8,14 @@ import (
 var Logger *slog.Logger
 
 func InitLogger() {
+	Logger = New()
+}
+
+// New returns the default JSON logger on stderr
+func New() *slog.Logger {
 	jsonHandler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	})
 
-	Logger = slog.New(jsonHandler)
+	return slog.New(jsonHandler)
 }
@@ -0,0 +1,74 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// DetectorAzure adds the virtual machine the process runs on from the Azure instance metadata service
+const DetectorAzure = "azure"
+
+// AzureMetadataEndpoint is the base URL of the Azure instance metadata service, replaced in tests
+var AzureMetadataEndpoint = "http://169.254.169.254"
+
+// AzureCompute is the part of the compute document of the Azure instance metadata service gotel uses
+type AzureCompute struct {
+	Location       string `json:"location"`
+	Name           string `json:"name"`
+	SubscriptionID string `json:"subscriptionId"`
+	VMID           string `json:"vmId"`
+	VMSize         string `json:"vmSize"`
+	Zone           string `json:"zone"`
+}
+
+var azureCache detectorCache
+
+// azureAttributes returns the cloud.* and host.* attributes of the Azure VM, or nothing off Azure
+func azureAttributes(ctx context.Context) []attribute.KeyValue {
+	return azureCache.get(ctx, func(ctx context.Context) []attribute.KeyValue {
+		compute, err := fetchAzureCompute(ctx, AzureMetadataEndpoint)
+		if err != nil {
+			return nil
+		}
+
+		return appendIfSet([]attribute.KeyValue{semconv.CloudProviderAzure, semconv.CloudPlatformAzureVM},
+			semconv.CloudRegion(compute.Location),
+			semconv.CloudAvailabilityZone(compute.Zone),
+			semconv.CloudAccountID(compute.SubscriptionID),
+			semconv.HostID(compute.VMID),
+			semconv.HostName(compute.Name),
+			semconv.HostType(compute.VMSize),
+		)
+	})
+}
+
+// fetchAzureCompute reads the compute document of the instance metadata service
+func fetchAzureCompute(ctx context.Context, endpoint string) (*AzureCompute, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/metadata/instance/compute?api-version=2021-12-13&format=json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := imdsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure instance metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get Azure instance metadata: %s", resp.Status)
+	}
+
+	compute := new(AzureCompute)
+	if err = json.NewDecoder(resp.Body).Decode(compute); err != nil {
+		return nil, fmt.Errorf("failed to decode Azure instance metadata: %w", err)
+	}
+
+	return compute, nil
+}
@@ -0,0 +1,66 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setAzureEndpoint points the detector at endpoint with an empty cache
+func setAzureEndpoint(t *testing.T, endpoint string) {
+	original := AzureMetadataEndpoint
+	t.Cleanup(func() {
+		AzureMetadataEndpoint = original
+		azureCache = detectorCache{}
+	})
+
+	AzureMetadataEndpoint = endpoint
+	azureCache = detectorCache{}
+}
+
+func TestDetect_Azure(t *testing.T) {
+	t.Run("virtual machine", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "true" || r.URL.Path != "/metadata/instance/compute" || r.URL.Query().Get("api-version") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(AzureCompute{
+				Location:       "centralindia",
+				Name:           "checkout-vm-1",
+				SubscriptionID: "8d2f1c3a-5b4e-4f6a-9c7d-0e1f2a3b4c5d",
+				VMID:           "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+				VMSize:         "Standard_D4s_v5",
+				Zone:           "2",
+			})
+		}))
+		defer server.Close()
+		setAzureEndpoint(t, server.URL)
+
+		attrs, err := detect(DetectorAzure)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, "azure", found["cloud.provider"].AsString())
+		assert.Equal(t, "azure_vm", found["cloud.platform"].AsString())
+		assert.Equal(t, "centralindia", found["cloud.region"].AsString())
+		assert.Equal(t, "2", found["cloud.availability_zone"].AsString())
+		assert.Equal(t, "8d2f1c3a-5b4e-4f6a-9c7d-0e1f2a3b4c5d", found["cloud.account.id"].AsString())
+		assert.Equal(t, "02aab8a4-74ef-476e-8182-f6d2ba4166a6", found["host.id"].AsString())
+		assert.Equal(t, "checkout-vm-1", found["host.name"].AsString())
+		assert.Equal(t, "Standard_D4s_v5", found["host.type"].AsString())
+	})
+
+	t.Run("not on Azure", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		setAzureEndpoint(t, server.URL)
+
+		assert.Empty(t, azureAttributes(context.Background()))
+	})
+}
@@ -1,73 +1,184 @@
 package meta
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/GetSimpl/gotel/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
 // ECSMetadata represents the structure of ECS container metadata
 type ECSMetadata struct {
-	DockerId string `json:"DockerId"`
+	DockerId     string `json:"DockerId"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"`
+	ContainerARN string `json:"ContainerARN"`
 }
 
 var client = &http.Client{
 	Timeout: 2 * time.Second, // Short timeout to avoid blocking application startup
 }
 
-// GetContainerID returns the container ID using multiple detection methods in priority order:
-// 1. ECS_CONTAINER_METADATA_URI_V4 (AWS ECS Fargate/EC2) https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task-metadata-endpoint-v4.html
+// Files the container runtime ID is read from, replaced in tests
+var (
+	cgroupPath    = "/proc/self/cgroup"
+	mountinfoPath = "/proc/self/mountinfo"
+)
+
+// containerIDPattern matches the 64 hex character IDs of Docker, containerd, CRI-O and Podman
+var containerIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// mountContainerIDPattern matches the per-container directories bind mounted into a
+// container, e.g. /var/lib/docker/containers/<id>/hostname
+var mountContainerIDPattern = regexp.MustCompile(`/(?:containers|overlay-containers)/([0-9a-f]{64})/`)
+
+// Names of the container ID detectors, see ContainerIDDetectors
+const (
+	DetectorContainerIDECS      = "container.id.ecs"
+	DetectorContainerIDRuntime  = "container.id.runtime"
+	DetectorContainerIDHostname = "container.id.hostname"
+	DetectorContainerIDRandom   = "container.id.random"
+)
+
+// containerIDTimeout bounds each container ID detector of GetContainerID
+const containerIDTimeout = 2 * time.Second
+
+// ContainerIDDetectors returns the sources of container.id from the lowest to the highest
+// priority, DetectWith keeps the attribute of the last detector that finds one:
+// 1. Random UUID with "random-" prefix (fallback)
 // 2. HOSTNAME environment variable (Kubernetes pods)
-// 3. Random UUID with "random-" prefix (fallback)
-func GetContainerID() string {
-	// Try ECS metadata endpoint v4 (only modern ECS environments)
-	if metadataURI := os.Getenv("ECS_CONTAINER_METADATA_URI_V4"); metadataURI != "" {
-		if containerID := fetchECSContainerID(metadataURI); containerID != "" {
-			return containerID
-		}
+// 3. /proc/self/cgroup and /proc/self/mountinfo (Docker, containerd, CRI-O, Podman)
+// 4. ECS_CONTAINER_METADATA_URI_V4 (AWS ECS Fargate/EC2) https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task-metadata-endpoint-v4.html
+func ContainerIDDetectors() []Detector {
+	return []Detector{
+		containerIDDetector(DetectorContainerIDRandom, func(context.Context) string {
+			return generateRandomContainerID()
+		}),
+		containerIDDetector(DetectorContainerIDHostname, func(context.Context) string {
+			// Kubernetes pods often have meaningful hostnames
+			if hostname := os.Getenv("HOSTNAME"); hostname != "localhost" {
+				return hostname
+			}
+			return ""
+		}),
+		containerIDDetector(DetectorContainerIDRuntime, func(context.Context) string {
+			// The runtime's container ID survives restarts unlike a random ID
+			if containerID := readCgroupContainerID(cgroupPath); containerID != "" {
+				return containerID
+			}
+			return readMountinfoContainerID(mountinfoPath)
+		}),
+		containerIDDetector(DetectorContainerIDECS, func(ctx context.Context) string {
+			if metadataURI := os.Getenv("ECS_CONTAINER_METADATA_URI_V4"); metadataURI != "" {
+				return fetchECSContainerID(ctx, metadataURI)
+			}
+			return ""
+		}),
 	}
+}
+
+// containerIDDetector returns a Detector adding the container.id found by lookup, if any
+func containerIDDetector(name string, lookup func(ctx context.Context) string) Detector {
+	return NewDetector(name, func(ctx context.Context) ([]attribute.KeyValue, error) {
+		if containerID := lookup(ctx); containerID != "" {
+			return []attribute.KeyValue{semconv.ContainerID(containerID)}, nil
+		}
+		return nil, nil
+	})
+}
 
-	// Try hostname (Kubernetes pods often have meaningful hostnames)
-	if hostname := os.Getenv("HOSTNAME"); hostname != "" && hostname != "localhost" {
-		return hostname
+// DetectContainerID resolves container.id with ContainerIDDetectors, each given at most
+// timeout. A detector that fails or times out leaves it to the ones of lower priority.
+func DetectContainerID(ctx context.Context, timeout time.Duration) string {
+	detected, _ := DetectWith(ctx, timeout, ContainerIDDetectors()...)
+	if containerID, ok := detected.Set().Value(semconv.ContainerIDKey); ok {
+		return containerID.AsString()
 	}
 
-	// Final fallback: generate random UUID with prefix
 	return generateRandomContainerID()
 }
 
-// fetchECSContainerID fetches container ID from ECS metadata endpoint
-func fetchECSContainerID(metadataURI string) string {
-	resp, err := client.Get(metadataURI)
+// GetContainerID resolves container.id like DetectContainerID, giving each detector two seconds
+func GetContainerID() string {
+	return DetectContainerID(context.Background(), containerIDTimeout)
+}
+
+// fetchECSContainerID returns the container ID of the ECS container metadata, see fetchECSContainer
+func fetchECSContainerID(ctx context.Context, metadataURI string) string {
+	container, err := fetchECSContainer(ctx, metadataURI)
 	if err != nil {
 		return ""
 	}
 
-	defer func(Body io.ReadCloser) {
-		err = Body.Close()
-		if err != nil {
-			logger.Logger.Error("error closing ECS metadata response body", "err", err.Error())
-		}
-	}(resp.Body)
+	return container.DockerId
+}
 
-	if resp.StatusCode != http.StatusOK {
+// readCgroupContainerID returns the container ID in the cgroup paths of the process. Lines
+// look like 12:memory:/docker/<id> on cgroup v1 and 0::/system.slice/docker-<id>.scope on
+// cgroup v2. A private cgroup namespace shows 0::/ instead, see readMountinfoContainerID.
+func readCgroupContainerID(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
 		return ""
 	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
 
-	metadata := new(ECSMetadata)
+		// The ID is the last one in the path, e.g. /machine.slice/libpod-<id>.scope/container
+		id := ""
+		for _, segment := range strings.Split(fields[2], "/") {
+			segment = strings.TrimSuffix(segment, ".scope")
+			if i := strings.LastIndex(segment, "-"); i >= 0 {
+				segment = segment[i+1:] // docker-, cri-containerd-, crio-, libpod-
+			}
+			if containerIDPattern.MatchString(segment) {
+				id = segment
+			}
+		}
+		if id != "" {
+			return id
+		}
+	}
 
-	if err = json.NewDecoder(resp.Body).Decode(metadata); err != nil {
-		logger.Logger.Error("error decoding ECS metadata response", "err", err.Error())
+	return ""
+}
+
+// readMountinfoContainerID returns the container ID in the root of the mounts of the
+// process, where runtimes bind mount files like /etc/hostname from the container's directory
+func readMountinfoContainerID(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
 		return ""
 	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		if match := mountContainerIDPattern.FindStringSubmatch(fields[3]); match != nil {
+			return match[1]
+		}
+	}
 
-	return metadata.DockerId
+	return ""
 }
 
 // generateRandomContainerID creates a random UUID with "random-" prefix
@@ -1,19 +1,36 @@
 package meta
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"github.com/GetSimpl/gotel/pkg/logger"
 )
 
+// withoutRuntimeFiles points the cgroup and mountinfo paths at missing files, the test
+// process may itself run in a container
+func withoutRuntimeFiles(t *testing.T) {
+	originalCgroup, originalMountinfo := cgroupPath, mountinfoPath
+	t.Cleanup(func() { cgroupPath, mountinfoPath = originalCgroup, originalMountinfo })
+
+	cgroupPath = filepath.Join(t.TempDir(), "cgroup")
+	mountinfoPath = filepath.Join(t.TempDir(), "mountinfo")
+}
+
 func TestGetContainerID(t *testing.T) {
+	withoutRuntimeFiles(t)
+
 	tests := []struct {
 		name                   string
 		ecsMetadataURI         string
@@ -113,6 +130,44 @@ func TestGetContainerID(t *testing.T) {
 	}
 }
 
+func TestGetContainerID_Runtime(t *testing.T) {
+	const id = "a3f1c9e2b7d4058e6c1f2a9b3d7e4c5f8a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d"
+
+	t.Setenv("ECS_CONTAINER_METADATA_URI_V4", "")
+	t.Setenv("HOSTNAME", "a3f1c9e2b7d4")
+
+	tests := []struct {
+		name      string
+		cgroup    string
+		mountinfo string
+		expected  string
+	}{
+		{name: "docker cgroup v1", cgroup: "cgroup_v1_docker", expected: id},
+		{name: "containerd cgroup v1", cgroup: "cgroup_v1_containerd", expected: id},
+		{name: "docker cgroup v2", cgroup: "cgroup_v2_docker", expected: id},
+		{name: "containerd cgroup v2", cgroup: "cgroup_v2_containerd", expected: id},
+		{name: "cri-o cgroup v2", cgroup: "cgroup_v2_crio", expected: id},
+		{name: "podman cgroup v2", cgroup: "cgroup_v2_podman", expected: id},
+		{name: "docker mountinfo", cgroup: "cgroup_v2_private", mountinfo: "mountinfo_docker", expected: id},
+		{name: "podman mountinfo", cgroup: "cgroup_v2_private", mountinfo: "mountinfo_podman", expected: id},
+		{name: "not in a container - should return hostname", cgroup: "cgroup_v2_private", mountinfo: "mountinfo_host", expected: "a3f1c9e2b7d4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withoutRuntimeFiles(t)
+			if tt.cgroup != "" {
+				cgroupPath = filepath.Join("testdata", tt.cgroup)
+			}
+			if tt.mountinfo != "" {
+				mountinfoPath = filepath.Join("testdata", tt.mountinfo)
+			}
+
+			assert.Equal(t, tt.expected, GetContainerID())
+		})
+	}
+}
+
 func TestFetchECSContainerID(t *testing.T) {
 	logger.InitLogger()
 
@@ -167,14 +222,14 @@ func TestFetchECSContainerID(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(tt.mockResponse))
 			defer server.Close()
 
-			result := fetchECSContainerID(server.URL)
+			result := fetchECSContainerID(context.Background(), server.URL)
 			assert.Equal(t, tt.expectedResult, result)
 		})
 	}
 
 	// Test invalid URL
 	t.Run("invalid URL", func(t *testing.T) {
-		result := fetchECSContainerID("invalid-url")
+		result := fetchECSContainerID(context.Background(), "invalid-url")
 		assert.Empty(t, result)
 	})
 }
@@ -260,3 +315,49 @@ func TestGetContainerID_ECSIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectContainerID(t *testing.T) {
+	withoutRuntimeFiles(t)
+	t.Setenv("HOSTNAME", "checkout-7d9f8b6c5-x2x4q")
+
+	t.Run("ECS container document is fetched once", func(t *testing.T) {
+		var containerRequests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v4/shared":
+				containerRequests.Add(1)
+				_ = json.NewEncoder(w).Encode(ECSMetadata{DockerId: "ecs-container-123"})
+			case "/v4/shared/task":
+				_ = json.NewEncoder(w).Encode(ECSTaskMetadata{Family: "checkout"})
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+		t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL+"/v4/shared")
+
+		ecs, err := Lookup(DetectorECS)
+		require.NoError(t, err)
+		res, err := DetectWith(context.Background(), time.Second, append(ContainerIDDetectors(), ecs...)...)
+		require.NoError(t, err)
+
+		containerID, _ := res.Set().Value(semconv.ContainerIDKey)
+		assert.Equal(t, "ecs-container-123", containerID.AsString())
+		assert.Equal(t, "ecs-container-123", DetectContainerID(context.Background(), time.Second))
+		assert.Equal(t, int32(1), containerRequests.Load())
+	})
+
+	t.Run("timed out detector falls back", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+		t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL)
+
+		start := time.Now()
+		assert.Equal(t, "checkout-7d9f8b6c5-x2x4q", DetectContainerID(context.Background(), 50*time.Millisecond))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,108 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// DetectorEC2 adds the instance the process runs on from the EC2 instance metadata service
+const DetectorEC2 = "ec2"
+
+// EC2MetadataEndpoint is the base URL of the instance metadata service, replaced in tests
+var EC2MetadataEndpoint = "http://169.254.169.254"
+
+// EC2InstanceIdentity is the part of the instance identity document gotel uses
+type EC2InstanceIdentity struct {
+	AccountID        string `json:"accountId"`
+	AvailabilityZone string `json:"availabilityZone"`
+	InstanceID       string `json:"instanceId"`
+	InstanceType     string `json:"instanceType"`
+	ImageID          string `json:"imageId"`
+	Region           string `json:"region"`
+}
+
+// imdsClient gives up quickly, outside of the cloud the metadata addresses usually do not answer at all
+var imdsClient = &http.Client{
+	Timeout: time.Second,
+}
+
+var ec2Cache detectorCache
+
+// ec2Attributes returns the cloud.* and host.* attributes of the EC2 instance, or nothing
+// off EC2 or when AWS_EC2_METADATA_DISABLED is true
+func ec2Attributes(ctx context.Context) []attribute.KeyValue {
+	if disabled, _ := strconv.ParseBool(os.Getenv("AWS_EC2_METADATA_DISABLED")); disabled {
+		return nil
+	}
+
+	return ec2Cache.get(ctx, func(ctx context.Context) []attribute.KeyValue {
+		identity, err := fetchEC2InstanceIdentity(ctx, EC2MetadataEndpoint)
+		if err != nil {
+			return nil
+		}
+
+		return appendIfSet([]attribute.KeyValue{semconv.CloudProviderAWS, semconv.CloudPlatformAWSEC2},
+			semconv.CloudRegion(identity.Region),
+			semconv.CloudAvailabilityZone(identity.AvailabilityZone),
+			semconv.CloudAccountID(identity.AccountID),
+			semconv.HostID(identity.InstanceID),
+			semconv.HostType(identity.InstanceType),
+			semconv.HostImageID(identity.ImageID),
+		)
+	})
+}
+
+// fetchEC2InstanceIdentity reads the instance identity document with an IMDSv2 session token
+func fetchEC2InstanceIdentity(ctx context.Context, endpoint string) (*EC2InstanceIdentity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	resp, err := imdsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IMDS token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get IMDS token: %s", resp.Status)
+	}
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IMDS token: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/dynamic/instance-identity/document", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+
+	resp, err = imdsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance identity document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get instance identity document: %s", resp.Status)
+	}
+
+	identity := new(EC2InstanceIdentity)
+	if err = json.NewDecoder(resp.Body).Decode(identity); err != nil {
+		return nil, fmt.Errorf("failed to decode instance identity document: %w", err)
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,118 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIMDS starts an IMDSv2 stand-in serving identity and counting identity document requests
+func newIMDS(t *testing.T, identity EC2InstanceIdentity, requests *atomic.Int32) {
+	const token = "AQAEAFk3-imds-token"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(token))
+		case r.Method == http.MethodGet && r.URL.Path == "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			requests.Add(1)
+			_ = json.NewEncoder(w).Encode(identity)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	setEC2Endpoint(t, server.URL)
+}
+
+// setEC2Endpoint points the detector at endpoint with an empty cache
+func setEC2Endpoint(t *testing.T, endpoint string) {
+	original := EC2MetadataEndpoint
+	t.Cleanup(func() {
+		EC2MetadataEndpoint = original
+		ec2Cache = detectorCache{}
+	})
+
+	EC2MetadataEndpoint = endpoint
+	ec2Cache = detectorCache{}
+}
+
+func TestDetect_EC2(t *testing.T) {
+	identity := EC2InstanceIdentity{
+		AccountID:        "111122223333",
+		AvailabilityZone: "ap-south-1b",
+		InstanceID:       "i-0abc123def4567890",
+		InstanceType:     "m6i.large",
+		ImageID:          "ami-0f5ee92e2d63afc18",
+		Region:           "ap-south-1",
+	}
+
+	t.Run("instance identity", func(t *testing.T) {
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+		var requests atomic.Int32
+		newIMDS(t, identity, &requests)
+
+		attrs, err := detect(DetectorEC2)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, "aws", found["cloud.provider"].AsString())
+		assert.Equal(t, "aws_ec2", found["cloud.platform"].AsString())
+		assert.Equal(t, "ap-south-1", found["cloud.region"].AsString())
+		assert.Equal(t, "ap-south-1b", found["cloud.availability_zone"].AsString())
+		assert.Equal(t, "111122223333", found["cloud.account.id"].AsString())
+		assert.Equal(t, "i-0abc123def4567890", found["host.id"].AsString())
+		assert.Equal(t, "m6i.large", found["host.type"].AsString())
+		assert.Equal(t, "ami-0f5ee92e2d63afc18", found["host.image.id"].AsString())
+
+		// The second lookup is served from the cache
+		again, err := detect(DetectorEC2)
+		require.NoError(t, err)
+		assert.Equal(t, attrs, again)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+		var requests atomic.Int32
+		newIMDS(t, identity, &requests)
+
+		assert.Empty(t, ec2Attributes(context.Background()))
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("token rejected", func(t *testing.T) {
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		setEC2Endpoint(t, server.URL)
+
+		assert.Empty(t, ec2Attributes(context.Background()))
+	})
+
+	t.Run("not on EC2", func(t *testing.T) {
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		setEC2Endpoint(t, server.URL)
+
+		assert.Empty(t, ec2Attributes(context.Background()))
+	})
+}
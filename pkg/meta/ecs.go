@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// DetectorECS adds the task and container of the ECS task metadata endpoint v4
+const DetectorECS = "ecs"
+
+// ECSTaskMetadata is the part of the ${ECS_CONTAINER_METADATA_URI_V4}/task document gotel uses
+type ECSTaskMetadata struct {
+	Cluster          string `json:"Cluster"` // cluster name on EC2, cluster ARN on Fargate
+	TaskARN          string `json:"TaskARN"`
+	Family           string `json:"Family"`
+	Revision         string `json:"Revision"`
+	AvailabilityZone string `json:"AvailabilityZone"`
+	LaunchType       string `json:"LaunchType"`
+}
+
+// ecsAttributes returns the aws.ecs.*, cloud.* and container.* attributes of the task the
+// process runs in, or nothing outside of ECS
+func ecsAttributes(ctx context.Context) []attribute.KeyValue {
+	metadataURI := os.Getenv("ECS_CONTAINER_METADATA_URI_V4")
+	if metadataURI == "" {
+		return nil
+	}
+
+	container, err := fetchECSContainer(ctx, metadataURI)
+	if err != nil {
+		return nil
+	}
+	task := new(ECSTaskMetadata)
+	if err := getJSON(ctx, strings.TrimRight(metadataURI, "/")+"/task", task); err != nil {
+		return nil
+	}
+
+	return ecsResourceAttributes(container, task)
+}
+
+// ecsContainerCache keeps the container document of the first successful lookup, the ecs
+// detector and the container ID detectors both read it
+var ecsContainerCache struct {
+	mutex       sync.Mutex
+	metadataURI string
+	container   *ECSMetadata
+}
+
+// fetchECSContainer returns the ${ECS_CONTAINER_METADATA_URI_V4} container document
+func fetchECSContainer(ctx context.Context, metadataURI string) (*ECSMetadata, error) {
+	ecsContainerCache.mutex.Lock()
+	defer ecsContainerCache.mutex.Unlock()
+
+	if ecsContainerCache.container != nil && ecsContainerCache.metadataURI == metadataURI {
+		return ecsContainerCache.container, nil
+	}
+
+	container := new(ECSMetadata)
+	if err := getJSON(ctx, metadataURI, container); err != nil {
+		return nil, err
+	}
+
+	ecsContainerCache.metadataURI, ecsContainerCache.container = metadataURI, container
+	return container, nil
+}
+
+// ecsResourceAttributes maps the container and task documents to resource attributes
+func ecsResourceAttributes(container *ECSMetadata, task *ECSTaskMetadata) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderAWS,
+		semconv.CloudPlatformAWSECS,
+	}
+
+	// arn:aws:ecs:<region>:<account>:task/<cluster>/<id>
+	arn := strings.Split(task.TaskARN, ":")
+	if len(arn) >= 6 {
+		attrs = append(attrs, semconv.CloudRegion(arn[3]), semconv.CloudAccountID(arn[4]))
+	}
+
+	cluster := task.Cluster
+	if cluster != "" && !strings.HasPrefix(cluster, "arn:") && len(arn) >= 6 {
+		cluster = fmt.Sprintf("arn:%s:ecs:%s:%s:cluster/%s", arn[1], arn[3], arn[4], cluster)
+	}
+
+	attrs = appendIfSet(attrs,
+		semconv.AWSECSClusterARN(cluster),
+		semconv.AWSECSTaskARN(task.TaskARN),
+		semconv.AWSECSTaskFamily(task.Family),
+		semconv.AWSECSTaskRevision(task.Revision),
+		semconv.AWSECSLaunchtypeKey.String(strings.ToLower(task.LaunchType)),
+		semconv.CloudAvailabilityZone(task.AvailabilityZone),
+		semconv.AWSECSContainerARN(container.ContainerARN),
+		semconv.ContainerID(container.DockerId),
+		semconv.ContainerName(container.Name),
+	)
+
+	// The image is reported as <name>:<tag>, a registry port also contains a colon
+	if container.Image != "" {
+		name, tag := container.Image, ""
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			name, tag = name[:i], name[i+1:]
+		}
+		attrs = appendIfSet(attrs, semconv.ContainerImageName(name), semconv.ContainerImageTag(tag))
+	}
+
+	return attrs
+}
+
+// getJSON decodes the JSON document at url into v
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// appendIfSet appends the attributes with a non-empty string value
+func appendIfSet(attrs []attribute.KeyValue, candidates ...attribute.KeyValue) []attribute.KeyValue {
+	for _, attr := range candidates {
+		if attr.Value.AsString() != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
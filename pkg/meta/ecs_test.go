@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect_ECS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4/abc":
+			_ = json.NewEncoder(w).Encode(ECSMetadata{
+				DockerId:     "cd189a933e5849daa93386466019ab50-2495160603",
+				Name:         "checkout",
+				Image:        "registry.acme.io:5000/checkout:v1.4.2",
+				ContainerARN: "arn:aws:ecs:ap-south-1:111122223333:container/prod/158d1c8083dd49d6b527399fd6414f5c/1b6b1d7e",
+			})
+		case "/v4/abc/task":
+			_ = json.NewEncoder(w).Encode(ECSTaskMetadata{
+				Cluster:          "prod",
+				TaskARN:          "arn:aws:ecs:ap-south-1:111122223333:task/prod/158d1c8083dd49d6b527399fd6414f5c",
+				Family:           "checkout",
+				Revision:         "7",
+				AvailabilityZone: "ap-south-1a",
+				LaunchType:       "FARGATE",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("task and container", func(t *testing.T) {
+		t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL+"/v4/abc")
+
+		attrs, err := detect(DetectorECS)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, "aws", found["cloud.provider"].AsString())
+		assert.Equal(t, "aws_ecs", found["cloud.platform"].AsString())
+		assert.Equal(t, "ap-south-1", found["cloud.region"].AsString())
+		assert.Equal(t, "111122223333", found["cloud.account.id"].AsString())
+		assert.Equal(t, "ap-south-1a", found["cloud.availability_zone"].AsString())
+		assert.Equal(t, "arn:aws:ecs:ap-south-1:111122223333:cluster/prod", found["aws.ecs.cluster.arn"].AsString())
+		assert.Equal(t, "arn:aws:ecs:ap-south-1:111122223333:task/prod/158d1c8083dd49d6b527399fd6414f5c", found["aws.ecs.task.arn"].AsString())
+		assert.Equal(t, "checkout", found["aws.ecs.task.family"].AsString())
+		assert.Equal(t, "7", found["aws.ecs.task.revision"].AsString())
+		assert.Equal(t, "fargate", found["aws.ecs.launchtype"].AsString())
+		assert.Equal(t, "cd189a933e5849daa93386466019ab50-2495160603", found["container.id"].AsString())
+		assert.Equal(t, "checkout", found["container.name"].AsString())
+		assert.Equal(t, "registry.acme.io:5000/checkout", found["container.image.name"].AsString())
+		assert.Equal(t, "v1.4.2", found["container.image.tag"].AsString())
+	})
+
+	t.Run("endpoint error", func(t *testing.T) {
+		t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL+"/v4/missing")
+
+		attrs, err := detect(DetectorECS)
+		require.NoError(t, err)
+		assert.Empty(t, attrs)
+	})
+
+	t.Run("outside of ECS", func(t *testing.T) {
+		t.Setenv("ECS_CONTAINER_METADATA_URI_V4", "")
+
+		attrs, err := detect(DetectorECS)
+		require.NoError(t, err)
+		assert.Empty(t, attrs)
+	})
+}
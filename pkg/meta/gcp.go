@@ -0,0 +1,86 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// DetectorGCP adds the instance the process runs on from the GCE metadata server
+const DetectorGCP = "gcp"
+
+// GCPMetadataEndpoint is the base URL of the GCE metadata server, replaced in tests
+var GCPMetadataEndpoint = "http://metadata.google.internal"
+
+var gcpCache detectorCache
+
+// gcpAttributes returns the cloud.* and host.* attributes of the GCE instance, with
+// k8s.cluster.name on GKE nodes, or nothing off GCP
+func gcpAttributes(ctx context.Context) []attribute.KeyValue {
+	return gcpCache.get(ctx, func(ctx context.Context) []attribute.KeyValue {
+		projectID, err := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "project/project-id")
+		if err != nil {
+			return nil
+		}
+
+		attrs := []attribute.KeyValue{semconv.CloudProviderGCP, semconv.CloudAccountID(projectID)}
+
+		// GKE nodes carry the cluster name as an instance attribute
+		if cluster, _ := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "instance/attributes/cluster-name"); cluster != "" {
+			attrs = append(attrs, semconv.CloudPlatformGCPKubernetesEngine, semconv.K8SClusterName(cluster))
+		} else {
+			attrs = append(attrs, semconv.CloudPlatformGCPComputeEngine)
+		}
+
+		// projects/<number>/zones/<region>-<zone>
+		if zone, err := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "instance/zone"); err == nil && zone != "" {
+			zone = zone[strings.LastIndex(zone, "/")+1:]
+			attrs = append(attrs, semconv.CloudAvailabilityZone(zone))
+			if i := strings.LastIndex(zone, "-"); i > 0 {
+				attrs = append(attrs, semconv.CloudRegion(zone[:i]))
+			}
+		}
+
+		id, _ := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "instance/id")
+		name, _ := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "instance/name")
+		machineType, _ := fetchGCPMetadata(ctx, GCPMetadataEndpoint, "instance/machine-type")
+
+		return appendIfSet(attrs,
+			semconv.HostID(id),
+			semconv.HostName(name),
+			semconv.HostType(machineType[strings.LastIndex(machineType, "/")+1:]), // projects/<number>/machineTypes/<type>
+		)
+	})
+}
+
+// fetchGCPMetadata returns the value at path below /computeMetadata/v1/
+func fetchGCPMetadata(ctx context.Context, endpoint, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := imdsClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get GCE metadata %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	// Anything else answering on the address is not the metadata server
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Metadata-Flavor") != "Google" {
+		return "", fmt.Errorf("failed to get GCE metadata %s: %s", path, resp.Status)
+	}
+
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GCE metadata %s: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(value)), nil
+}
@@ -0,0 +1,92 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGCEMetadataServer starts a GCE metadata server stand-in serving values by path
+func newGCEMetadataServer(t *testing.T, values map[string]string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		value, ok := values[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		_, _ = w.Write([]byte(value))
+	}))
+	t.Cleanup(server.Close)
+
+	setGCPEndpoint(t, server.URL)
+}
+
+// setGCPEndpoint points the detector at endpoint with an empty cache
+func setGCPEndpoint(t *testing.T, endpoint string) {
+	original := GCPMetadataEndpoint
+	t.Cleanup(func() {
+		GCPMetadataEndpoint = original
+		gcpCache = detectorCache{}
+	})
+
+	GCPMetadataEndpoint = endpoint
+	gcpCache = detectorCache{}
+}
+
+func TestDetect_GCP(t *testing.T) {
+	instance := map[string]string{
+		"/computeMetadata/v1/project/project-id":    "acme-prod",
+		"/computeMetadata/v1/instance/id":           "4520031799277581759",
+		"/computeMetadata/v1/instance/name":         "checkout-1",
+		"/computeMetadata/v1/instance/zone":         "projects/123456789012/zones/asia-south1-c",
+		"/computeMetadata/v1/instance/machine-type": "projects/123456789012/machineTypes/e2-standard-4",
+	}
+
+	t.Run("compute engine", func(t *testing.T) {
+		newGCEMetadataServer(t, instance)
+
+		attrs, err := detect(DetectorGCP)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, "gcp", found["cloud.provider"].AsString())
+		assert.Equal(t, "gcp_compute_engine", found["cloud.platform"].AsString())
+		assert.Equal(t, "acme-prod", found["cloud.account.id"].AsString())
+		assert.Equal(t, "asia-south1", found["cloud.region"].AsString())
+		assert.Equal(t, "asia-south1-c", found["cloud.availability_zone"].AsString())
+		assert.Equal(t, "4520031799277581759", found["host.id"].AsString())
+		assert.Equal(t, "checkout-1", found["host.name"].AsString())
+		assert.Equal(t, "e2-standard-4", found["host.type"].AsString())
+	})
+
+	t.Run("kubernetes engine", func(t *testing.T) {
+		gke := map[string]string{"/computeMetadata/v1/instance/attributes/cluster-name": "prod-cluster"}
+		for path, value := range instance {
+			gke[path] = value
+		}
+		newGCEMetadataServer(t, gke)
+
+		found := attributeMap(gcpAttributes(context.Background()))
+		assert.Equal(t, "gcp_kubernetes_engine", found["cloud.platform"].AsString())
+		assert.Equal(t, "prod-cluster", found["k8s.cluster.name"].AsString())
+	})
+
+	t.Run("not the metadata server", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("acme-prod"))
+		}))
+		defer server.Close()
+		setGCPEndpoint(t, server.URL)
+
+		assert.Empty(t, gcpAttributes(context.Background()))
+	})
+}
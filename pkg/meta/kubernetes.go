@@ -0,0 +1,66 @@
+package meta
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// DetectorKubernetes adds the pod, namespace, node and container exposed through the downward API
+const DetectorKubernetes = "k8s"
+
+// KubernetesEnvVars names the environment variables the pod spec sets from the downward API,
+// e.g. valueFrom.fieldRef.fieldPath: metadata.name for PodName
+type KubernetesEnvVars struct {
+	PodName       string
+	PodUID        string
+	Namespace     string
+	NodeName      string
+	ContainerName string
+}
+
+// KubernetesEnv is read by the k8s detector, change it before gotel.New when the pod spec
+// uses other names
+var KubernetesEnv = KubernetesEnvVars{
+	PodName:       "K8S_POD_NAME",
+	PodUID:        "K8S_POD_UID",
+	Namespace:     "K8S_NAMESPACE_NAME",
+	NodeName:      "K8S_NODE_NAME",
+	ContainerName: "K8S_CONTAINER_NAME",
+}
+
+// KubernetesNamespaceFile is mounted into every pod with a service account token
+var KubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// kubernetesAttributes returns the k8s.* attributes of the pod the process runs in, or
+// nothing outside of Kubernetes
+func kubernetesAttributes(context.Context) []attribute.KeyValue {
+	namespace := os.Getenv(KubernetesEnv.Namespace)
+	if namespace == "" {
+		if data, err := os.ReadFile(KubernetesNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	_, inCluster := os.LookupEnv("KUBERNETES_SERVICE_HOST")
+	if namespace == "" && !inCluster {
+		return nil
+	}
+
+	// The hostname of a pod is its name unless the pod spec sets hostname
+	podName := os.Getenv(KubernetesEnv.PodName)
+	if podName == "" {
+		podName = os.Getenv("HOSTNAME")
+	}
+
+	return appendIfSet(nil,
+		semconv.K8SNamespaceName(namespace),
+		semconv.K8SPodName(podName),
+		semconv.K8SPodUID(os.Getenv(KubernetesEnv.PodUID)),
+		semconv.K8SNodeName(os.Getenv(KubernetesEnv.NodeName)),
+		semconv.K8SContainerName(os.Getenv(KubernetesEnv.ContainerName)),
+	)
+}
@@ -0,0 +1,87 @@
+package meta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestDetect_Kubernetes(t *testing.T) {
+	setNamespaceFile := func(t *testing.T, content string) {
+		original := KubernetesNamespaceFile
+		t.Cleanup(func() { KubernetesNamespaceFile = original })
+
+		KubernetesNamespaceFile = filepath.Join(t.TempDir(), "namespace")
+		if content != "" {
+			require.NoError(t, os.WriteFile(KubernetesNamespaceFile, []byte(content), 0o644))
+		}
+	}
+	clearEnv := func(t *testing.T) {
+		for _, name := range []string{"KUBERNETES_SERVICE_HOST", "HOSTNAME", KubernetesEnv.PodName,
+			KubernetesEnv.PodUID, KubernetesEnv.Namespace, KubernetesEnv.NodeName, KubernetesEnv.ContainerName} {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+
+	t.Run("downward API and namespace file", func(t *testing.T) {
+		clearEnv(t)
+		setNamespaceFile(t, "payments\n")
+		t.Setenv("K8S_POD_NAME", "checkout-7d9f8b6c5-x2x4q")
+		t.Setenv("K8S_POD_UID", "4f1c2e0a-8b5d-4c3e-9a7f-1d2e3f4a5b6c")
+		t.Setenv("K8S_NODE_NAME", "ip-10-0-1-12")
+		t.Setenv("K8S_CONTAINER_NAME", "checkout")
+
+		attrs, err := detect(DetectorKubernetes)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, "payments", found["k8s.namespace.name"].AsString())
+		assert.Equal(t, "checkout-7d9f8b6c5-x2x4q", found["k8s.pod.name"].AsString())
+		assert.Equal(t, "4f1c2e0a-8b5d-4c3e-9a7f-1d2e3f4a5b6c", found["k8s.pod.uid"].AsString())
+		assert.Equal(t, "ip-10-0-1-12", found["k8s.node.name"].AsString())
+		assert.Equal(t, "checkout", found["k8s.container.name"].AsString())
+	})
+
+	t.Run("custom env vars", func(t *testing.T) {
+		clearEnv(t)
+		setNamespaceFile(t, "payments")
+
+		original := KubernetesEnv
+		defer func() { KubernetesEnv = original }()
+		KubernetesEnv.PodName = "POD_NAME"
+		KubernetesEnv.Namespace = "POD_NAMESPACE"
+
+		t.Setenv("POD_NAME", "checkout-0")
+		t.Setenv("POD_NAMESPACE", "orders")
+
+		found := attributeMap(kubernetesAttributes(context.Background()))
+		assert.Equal(t, "orders", found["k8s.namespace.name"].AsString(), "env var wins over the namespace file")
+		assert.Equal(t, "checkout-0", found["k8s.pod.name"].AsString())
+		assert.NotContains(t, found, attribute.Key("k8s.node.name"))
+	})
+
+	t.Run("pod name from hostname", func(t *testing.T) {
+		clearEnv(t)
+		setNamespaceFile(t, "")
+		t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+		t.Setenv("HOSTNAME", "checkout-0")
+
+		found := attributeMap(kubernetesAttributes(context.Background()))
+		assert.Equal(t, "checkout-0", found["k8s.pod.name"].AsString())
+		assert.NotContains(t, found, attribute.Key("k8s.namespace.name"))
+	})
+
+	t.Run("outside of Kubernetes", func(t *testing.T) {
+		clearEnv(t)
+		setNamespaceFile(t, "")
+		t.Setenv("HOSTNAME", "laptop")
+
+		assert.Empty(t, kubernetesAttributes(context.Background()))
+	})
+}
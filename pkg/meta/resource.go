@@ -0,0 +1,322 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Names of the built-in resource detectors, see Detect
+const (
+	DetectorHost    = "host"
+	DetectorOS      = "os"
+	DetectorProcess = "process"
+	DetectorBuild   = "build"
+)
+
+// Build info attribute keys, named after the settings of debug.BuildInfo
+const (
+	VCSRevisionKey   = attribute.Key("vcs.revision")
+	VCSTimeKey       = attribute.Key("vcs.time")
+	VCSModifiedKey   = attribute.Key("vcs.modified")
+	ModulePathKey    = attribute.Key("go.module.path")
+	ModuleVersionKey = attribute.Key("go.module.version")
+)
+
+// Detector finds resource attributes describing where the process runs. A detector that
+// does not apply, e.g. ecs outside of ECS, returns no attributes and no error.
+type Detector interface {
+	// Name identifies the detector in OTEL_RESOURCE_DETECTORS
+	Name() string
+	// Detect returns the attributes found, it should give up once ctx is done
+	Detect(ctx context.Context) ([]attribute.KeyValue, error)
+}
+
+// NewDetector returns a Detector calling detect
+func NewDetector(name string, detect func(ctx context.Context) ([]attribute.KeyValue, error)) Detector {
+	return &funcDetector{name: name, detect: detect}
+}
+
+type funcDetector struct {
+	name   string
+	detect func(ctx context.Context) ([]attribute.KeyValue, error)
+}
+
+func (d *funcDetector) Name() string {
+	return d.name
+}
+
+func (d *funcDetector) Detect(ctx context.Context) ([]attribute.KeyValue, error) {
+	return d.detect(ctx)
+}
+
+// registry holds the detectors in the order they were registered
+var registry struct {
+	mutex     sync.RWMutex
+	detectors []Detector
+	byName    map[string]Detector
+}
+
+func init() {
+	builtins := []struct {
+		name   string
+		detect func(context.Context) []attribute.KeyValue
+	}{
+		{DetectorHost, hostAttributes},
+		{DetectorOS, osAttributes},
+		{DetectorProcess, processAttributes},
+		{DetectorBuild, buildAttributes},
+		{DetectorECS, ecsAttributes},
+		{DetectorKubernetes, kubernetesAttributes},
+		{DetectorEC2, ec2Attributes},
+		{DetectorGCP, gcpAttributes},
+		{DetectorAzure, azureAttributes},
+	}
+
+	for _, builtin := range builtins {
+		RegisterDetector(NewDetector(builtin.name, func(ctx context.Context) ([]attribute.KeyValue, error) {
+			return builtin.detect(ctx), nil
+		}))
+	}
+}
+
+// RegisterDetector makes d available by name to Detect and OTEL_RESOURCE_DETECTORS. Like
+// sql.Register it is meant to be called from init and panics when the name is empty or taken.
+func RegisterDetector(d Detector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	name := d.Name()
+	if name == "" {
+		panic("meta: resource detector without a name")
+	}
+	if _, ok := registry.byName[name]; ok {
+		panic(fmt.Sprintf("meta: resource detector %q registered twice", name))
+	}
+
+	if registry.byName == nil {
+		registry.byName = make(map[string]Detector)
+	}
+	registry.byName[name] = d
+	registry.detectors = append(registry.detectors, d)
+}
+
+// Detectors returns the names of the registered detectors in registration order
+func Detectors() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	names := make([]string, len(registry.detectors))
+	for i, d := range registry.detectors {
+		names[i] = d.Name()
+	}
+	return names
+}
+
+// IsDetector reports whether name is a registered resource detector
+func IsDetector(name string) bool {
+	_, err := Lookup(name)
+	return err == nil
+}
+
+// Lookup returns the registered detectors with the given names
+func Lookup(names ...string) ([]Detector, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	detectors := make([]Detector, 0, len(names))
+	for _, name := range names {
+		d, ok := registry.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource detector %q", name)
+		}
+		detectors = append(detectors, d)
+	}
+
+	return detectors, nil
+}
+
+// Detect runs the named detectors, see DetectWith
+func Detect(ctx context.Context, timeout time.Duration, names ...string) (*resource.Resource, error) {
+	detectors, err := Lookup(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	return DetectWith(ctx, timeout, detectors...)
+}
+
+// DetectWith runs detectors concurrently, each given at most timeout, and merges their
+// attributes into one resource. When detectors disagree on an attribute, the later one wins.
+// Failed and timed out detectors are left out of the resource and reported in the error,
+// which comes with the resource of the others.
+func DetectWith(ctx context.Context, timeout time.Duration, detectors ...Detector) (*resource.Resource, error) {
+	type result struct {
+		attrs []attribute.KeyValue
+		err   error
+	}
+	results := make([]result, len(detectors))
+
+	var wg sync.WaitGroup
+	for i, d := range detectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attrs, err := detectWithTimeout(ctx, timeout, d)
+			results[i] = result{attrs, err}
+		}()
+	}
+	wg.Wait()
+
+	var attrs []attribute.KeyValue
+	var errs []error
+	for _, r := range results {
+		attrs = append(attrs, r.attrs...)
+		errs = append(errs, r.err)
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), errors.Join(errs...)
+}
+
+// detectWithTimeout runs d and stops waiting for it after timeout, even when d ignores ctx
+func detectWithTimeout(ctx context.Context, timeout time.Duration, d Detector) ([]attribute.KeyValue, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		attrs []attribute.KeyValue
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		attrs, err := d.Detect(ctx)
+		done <- result{attrs, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("resource detector %q failed: %w", d.Name(), r.err)
+		}
+		return r.attrs, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("resource detector %q gave up after %s: %w", d.Name(), timeout, ctx.Err())
+	}
+}
+
+// detectorCache keeps the attributes of the first complete metadata service lookup, the
+// machine does not change while the process runs
+type detectorCache struct {
+	mutex sync.Mutex
+	done  bool
+	attrs []attribute.KeyValue
+}
+
+// get returns the attributes of the first call to lookup that ctx did not cut short
+func (c *detectorCache) get(ctx context.Context, lookup func(ctx context.Context) []attribute.KeyValue) []attribute.KeyValue {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.done {
+		return c.attrs
+	}
+
+	attrs := lookup(ctx)
+	if ctx.Err() == nil {
+		c.done, c.attrs = true, attrs
+	}
+	return attrs
+}
+
+// readBuildInfo is replaced in tests, binaries built by go test have no VCS settings
+var readBuildInfo = debug.ReadBuildInfo
+
+// hostAttributes returns host.name and host.arch
+func hostAttributes(context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.HostName(hostname))
+	}
+
+	switch runtime.GOARCH {
+	case "amd64":
+		attrs = append(attrs, semconv.HostArchAMD64)
+	case "arm64":
+		attrs = append(attrs, semconv.HostArchARM64)
+	case "arm":
+		attrs = append(attrs, semconv.HostArchARM32)
+	case "386":
+		attrs = append(attrs, semconv.HostArchX86)
+	case "ppc64", "ppc64le":
+		attrs = append(attrs, semconv.HostArchPPC64)
+	default:
+		attrs = append(attrs, semconv.HostArchKey.String(runtime.GOARCH))
+	}
+
+	return attrs
+}
+
+// osAttributes returns os.type
+func osAttributes(context.Context) []attribute.KeyValue {
+	switch runtime.GOOS {
+	case "dragonfly":
+		return []attribute.KeyValue{semconv.OSTypeDragonflyBSD}
+	case "zos":
+		return []attribute.KeyValue{semconv.OSTypeZOS}
+	default:
+		// The remaining os.type values match GOOS
+		return []attribute.KeyValue{semconv.OSTypeKey.String(runtime.GOOS)}
+	}
+}
+
+// processAttributes returns the process ID, executable and Go runtime of the process
+func processAttributes(context.Context) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.ProcessPID(os.Getpid()),
+		semconv.ProcessRuntimeName("go"),
+		semconv.ProcessRuntimeVersion(runtime.Version()),
+	}
+	if executable, err := os.Executable(); err == nil {
+		attrs = append(attrs, semconv.ProcessExecutableName(filepath.Base(executable)))
+	}
+
+	return attrs
+}
+
+// buildAttributes returns the main module version and the VCS revision the binary was built from
+func buildAttributes(context.Context) []attribute.KeyValue {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	if info.Main.Path != "" {
+		attrs = append(attrs, ModulePathKey.String(info.Main.Path))
+	}
+	if info.Main.Version != "" {
+		attrs = append(attrs, ModuleVersionKey.String(info.Main.Version))
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case string(VCSRevisionKey), string(VCSTimeKey):
+			attrs = append(attrs, attribute.String(setting.Key, setting.Value))
+		case string(VCSModifiedKey):
+			attrs = append(attrs, VCSModifiedKey.Bool(strings.EqualFold(setting.Value, "true")))
+		}
+	}
+
+	return attrs
+}
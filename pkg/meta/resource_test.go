@@ -0,0 +1,181 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attributeMap indexes attrs by key for assertions
+func attributeMap(attrs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value, len(attrs))
+	for _, attr := range attrs {
+		result[attr.Key] = attr.Value
+	}
+	return result
+}
+
+// detect runs the named detectors and returns the attributes of the merged resource
+func detect(names ...string) ([]attribute.KeyValue, error) {
+	res, err := Detect(context.Background(), time.Second, names...)
+	if res == nil {
+		return nil, err
+	}
+	return res.Attributes(), err
+}
+
+func TestDetect(t *testing.T) {
+	t.Run("host and os", func(t *testing.T) {
+		attrs, err := detect(DetectorHost, DetectorOS)
+		require.NoError(t, err)
+
+		hostname, err := os.Hostname()
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, hostname, found["host.name"].AsString())
+		assert.NotEmpty(t, found["host.arch"].AsString())
+		assert.Equal(t, runtime.GOOS, found["os.type"].AsString())
+	})
+
+	t.Run("process", func(t *testing.T) {
+		attrs, err := detect(DetectorProcess)
+		require.NoError(t, err)
+
+		found := attributeMap(attrs)
+		assert.Equal(t, int64(os.Getpid()), found["process.pid"].AsInt64())
+		assert.Equal(t, "go", found["process.runtime.name"].AsString())
+		assert.Equal(t, runtime.Version(), found["process.runtime.version"].AsString())
+		assert.NotEmpty(t, found["process.executable.name"].AsString())
+	})
+
+	t.Run("build info", func(t *testing.T) {
+		original := readBuildInfo
+		defer func() { readBuildInfo = original }()
+
+		readBuildInfo = func() (*debug.BuildInfo, bool) {
+			return &debug.BuildInfo{
+				Main: debug.Module{Path: "github.com/acme/checkout", Version: "v1.4.2"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs", Value: "git"},
+					{Key: "vcs.revision", Value: "9f3c2ab"},
+					{Key: "vcs.time", Value: "2025-06-01T10:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			}, true
+		}
+
+		attrs, err := detect(DetectorBuild)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			ModulePathKey.String("github.com/acme/checkout"),
+			ModuleVersionKey.String("v1.4.2"),
+			VCSRevisionKey.String("9f3c2ab"),
+			VCSTimeKey.String("2025-06-01T10:00:00Z"),
+			VCSModifiedKey.Bool(true),
+		}, attrs)
+
+		readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+		attrs, err = detect(DetectorBuild)
+		require.NoError(t, err)
+		assert.Empty(t, attrs)
+	})
+
+	t.Run("unknown detector", func(t *testing.T) {
+		_, err := detect(DetectorHost, "mainframe")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mainframe")
+		assert.False(t, IsDetector("mainframe"))
+	})
+}
+
+func TestDetectWith(t *testing.T) {
+	static := func(name string, attrs ...attribute.KeyValue) Detector {
+		return NewDetector(name, func(context.Context) ([]attribute.KeyValue, error) {
+			return attrs, nil
+		})
+	}
+	slow := func(name string, delay time.Duration, attrs ...attribute.KeyValue) Detector {
+		return NewDetector(name, func(ctx context.Context) ([]attribute.KeyValue, error) {
+			time.Sleep(delay) // ignores ctx on purpose
+			return attrs, nil
+		})
+	}
+
+	t.Run("later detectors win", func(t *testing.T) {
+		res, err := DetectWith(context.Background(), time.Second,
+			static("first", attribute.String("team", "payments"), attribute.String("tier", "1")),
+			static("second", attribute.String("team", "checkout")),
+		)
+		require.NoError(t, err)
+
+		found := attributeMap(res.Attributes())
+		assert.Equal(t, "checkout", found["team"].AsString())
+		assert.Equal(t, "1", found["tier"].AsString())
+	})
+
+	t.Run("detectors run concurrently", func(t *testing.T) {
+		start := time.Now()
+		res, err := DetectWith(context.Background(), time.Second,
+			slow("a", 200*time.Millisecond, attribute.String("a", "1")),
+			slow("b", 200*time.Millisecond, attribute.String("b", "1")),
+			slow("c", 200*time.Millisecond, attribute.String("c", "1")),
+		)
+		require.NoError(t, err)
+		assert.Len(t, res.Attributes(), 3)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("timeout and failure", func(t *testing.T) {
+		failing := NewDetector("failing", func(context.Context) ([]attribute.KeyValue, error) {
+			return nil, errors.New("metadata service unavailable")
+		})
+
+		start := time.Now()
+		res, err := DetectWith(context.Background(), 100*time.Millisecond,
+			static("fast", attribute.String("team", "payments")),
+			slow("stuck", time.Minute, attribute.String("stuck", "true")),
+			failing,
+		)
+		assert.Less(t, time.Since(start), time.Second)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), `"stuck"`)
+		assert.Contains(t, err.Error(), "metadata service unavailable")
+
+		found := attributeMap(res.Attributes())
+		assert.Equal(t, "payments", found["team"].AsString())
+		assert.NotContains(t, found, attribute.Key("stuck"))
+	})
+}
+
+func TestRegisterDetector(t *testing.T) {
+	assert.Equal(t, []string{"host", "os", "process", "build", "ecs", "k8s", "ec2", "gcp", "azure"}, Detectors())
+
+	RegisterDetector(NewDetector("acme-inventory", func(context.Context) ([]attribute.KeyValue, error) {
+		return []attribute.KeyValue{attribute.String("acme.rack", "b12")}, nil
+	}))
+
+	assert.True(t, IsDetector("acme-inventory"))
+	assert.Equal(t, "acme-inventory", Detectors()[len(Detectors())-1])
+
+	attrs, err := detect(DetectorOS, "acme-inventory")
+	require.NoError(t, err)
+	assert.Equal(t, "b12", attributeMap(attrs)["acme.rack"].AsString())
+
+	assert.Panics(t, func() {
+		RegisterDetector(NewDetector(DetectorHost, func(context.Context) ([]attribute.KeyValue, error) { return nil, nil }))
+	})
+	assert.Panics(t, func() {
+		RegisterDetector(NewDetector("", func(context.Context) ([]attribute.KeyValue, error) { return nil, nil }))
+	})
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+var (
+	ErrInvalidDefinition = errors.New("invalid metric definition")
+	ErrMetricConflict    = errors.New("metric conflict")
+)
+
+// Kind is the instrument a metric is recorded with
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+// Definition describes a metric before it is recorded, see Registry.Register. The
+// description is exported as the metric's help text, the unit and buckets are used when a
+// recording leaves them empty.
+type Definition struct {
+	Name        MetricName
+	Unit        Unit
+	Description string
+	Kind        Kind
+	// Buckets are the bucket boundaries of a histogram, the SDK defaults when empty
+	Buckets []float64
+}
+
+// Validate checks the kind and buckets of the definition, the name and unit are checked by
+// MetricName.Validate and Unit.Validate in the validation mode of a registry
+func (d Definition) Validate() error {
+	switch d.Kind {
+	case KindCounter, KindGauge:
+		if len(d.Buckets) > 0 {
+			return fmt.Errorf("%w: %q is a %s, only histograms have buckets", ErrInvalidDefinition, d.Name, d.Kind)
+		}
+	case KindHistogram:
+		if !sort.Float64sAreSorted(d.Buckets) {
+			return fmt.Errorf("%w: %q buckets must be in increasing order", ErrInvalidDefinition, d.Name)
+		}
+	default:
+		return fmt.Errorf("%w: %q kind must be %q, %q or %q, got %q", ErrInvalidDefinition, d.Name, KindCounter, KindGauge, KindHistogram, d.Kind)
+	}
+
+	return nil
+}
+
+// MetricConflictError is returned when a metric name is used with another kind, unit or
+// buckets than it was registered or first created with. It wraps ErrMetricConflict.
+type MetricConflictError struct {
+	Name MetricName
+	// Existing is the registered definition or the one of the first instrument of Name
+	Existing Definition
+	// Requested is the definition that conflicts with Existing
+	Requested Definition
+}
+
+func (e *MetricConflictError) Error() string {
+	switch {
+	case e.Existing.Kind != e.Requested.Kind:
+		return fmt.Sprintf("%v: %q is a %s, not a %s", ErrMetricConflict, e.Name, e.Existing.Kind, e.Requested.Kind)
+	case e.Existing.Unit != e.Requested.Unit:
+		return fmt.Sprintf("%v: %q has unit %q, not %q", ErrMetricConflict, e.Name, e.Existing.Unit, e.Requested.Unit)
+	default:
+		return fmt.Sprintf("%v: %q has buckets %v, not %v", ErrMetricConflict, e.Name, e.Existing.Buckets, e.Requested.Buckets)
+	}
+}
+
+func (e *MetricConflictError) Unwrap() error {
+	return ErrMetricConflict
+}
+
+// conflict returns a *MetricConflictError if requested differs from existing in kind, unit or
+// buckets. The description may differ.
+func conflict(existing, requested Definition) error {
+	if existing.Kind == requested.Kind && existing.Unit == requested.Unit && slices.Equal(existing.Buckets, requested.Buckets) {
+		return nil
+	}
+	return &MetricConflictError{Name: requested.Name, Existing: existing, Requested: requested}
+}
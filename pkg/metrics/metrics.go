@@ -3,6 +3,9 @@ package metrics
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
 
 	"github.com/GetSimpl/gotel/pkg/client"
@@ -13,6 +16,19 @@ var (
 	ErrHistBucketSizeTooLarge = errors.New("histogram bucket size is too large")
 )
 
+// DefaultMaxHistogramBuckets is the bucket limit used when Limits leaves it unset
+const DefaultMaxHistogramBuckets = 20
+
+// Limits bounds what a registry accepts
+type Limits struct {
+	// MaxHistogramBuckets is the maximum number of bucket boundaries, DefaultMaxHistogramBuckets when zero
+	MaxHistogramBuckets int
+	// Validation decides what happens to invalid metric names and units, ValidationWarn when empty
+	Validation ValidationMode
+	// Logger receives the problems found by validation, nothing is logged when nil
+	Logger *slog.Logger
+}
+
 type (
 	MetricName string
 	Unit       string
@@ -43,6 +59,7 @@ type Counter struct {
 // Gauge represents a gauge metric that wraps OTEL gauge
 type Gauge struct {
 	name      MetricName
+	unit      Unit
 	labels    map[string]string
 	otelGauge client.Gauge // underlying sdk gauge
 	ctx       context.Context
@@ -53,6 +70,8 @@ type Gauge struct {
 // Histogram represents a histogram metric that wraps OTEL histogram
 type Histogram struct {
 	name          MetricName
+	unit          Unit
+	buckets       []float64
 	labels        map[string]string
 	otelHistogram client.Histogram
 	ctx           context.Context
@@ -61,16 +80,20 @@ type Histogram struct {
 
 // registry holds all metrics and interfaces with OTEL client
 type registry struct {
-	counters   map[string]*Counter
-	gauges     map[string]*Gauge
-	histograms map[string]*Histogram
-	otelClient client.OTelClient
-	ctx        context.Context
-	mutex      sync.RWMutex
+	counters    map[string]*Counter
+	gauges      map[string]*Gauge
+	histograms  map[string]*Histogram
+	warned      map[string]bool           // problems already logged
+	descriptors map[MetricName]Definition // registered or taken from the first instrument of a name
+	limits      Limits
+	otelClient  client.OTelClient
+	ctx         context.Context
+	mutex       sync.RWMutex
 }
 
 // Registry is the public interface for metrics registry
 type Registry interface {
+	Register(defs ...Definition) error
 	GetOrCreateCounter(name MetricName, unit Unit, labels map[string]string) (*Counter, error)
 	GetOrCreateGauge(name MetricName, unit Unit, labels map[string]string) (*Gauge, error)
 	GetOrCreateHistogram(name MetricName, unit Unit, buckets []float64, labels map[string]string) (*Histogram, error)
@@ -79,22 +102,124 @@ type Registry interface {
 
 // NewRegistry creates a new metrics registry
 func NewRegistry(otelClient client.OTelClient, ctx context.Context) Registry {
+	return NewRegistryWithLimits(otelClient, ctx, Limits{})
+}
+
+// NewRegistryWithLimits creates a new metrics registry that enforces limits
+func NewRegistryWithLimits(otelClient client.OTelClient, ctx context.Context, limits Limits) Registry {
+	if limits.MaxHistogramBuckets <= 0 {
+		limits.MaxHistogramBuckets = DefaultMaxHistogramBuckets
+	}
+	if limits.Validation == "" {
+		limits.Validation = ValidationWarn
+	}
+
 	return &registry{
-		counters:   make(map[string]*Counter),
-		gauges:     make(map[string]*Gauge),
-		histograms: make(map[string]*Histogram),
-		otelClient: otelClient,
-		ctx:        ctx,
-		mutex:      sync.RWMutex{},
+		counters:    make(map[string]*Counter),
+		gauges:      make(map[string]*Gauge),
+		histograms:  make(map[string]*Histogram),
+		warned:      make(map[string]bool),
+		descriptors: make(map[MetricName]Definition),
+		limits:      limits,
+		otelClient:  otelClient,
+		ctx:         ctx,
+		mutex:       sync.RWMutex{},
+	}
+}
+
+// Register adds metric definitions used when the metrics are created. A definition with
+// another kind, unit or buckets than the earlier definition or instruments of its name is a
+// conflict, see GetOrCreateCounter. Nothing is registered when one of defs is rejected.
+func (r *registry) Register(defs ...Definition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if len(def.Buckets) > r.limits.MaxHistogramBuckets {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidDefinition, def.Name, ErrHistBucketSizeTooLarge)
+		}
+		if err := r.validate(def.Name, def.Unit); err != nil {
+			return err
+		}
+		if existing, ok := r.descriptors[def.Name]; ok {
+			if err := r.checkConflict(existing, def); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, def := range defs {
+		r.descriptors[def.Name] = def
 	}
+
+	return nil
+}
+
+// descriptor returns the descriptor of a new instrument of name, with the unit, buckets and
+// description of the existing descriptor when it is of the same kind and unit or buckets are
+// left empty. Must be called with the write lock held.
+func (r *registry) descriptor(name MetricName, kind Kind, unit Unit, buckets []float64) (Definition, error) {
+	requested := Definition{Name: name, Kind: kind, Unit: unit, Buckets: buckets}
+
+	existing, ok := r.descriptors[name]
+	if !ok {
+		return requested, nil
+	}
+
+	if existing.Kind == kind {
+		requested.Description = existing.Description
+		if requested.Unit == "" {
+			requested.Unit = existing.Unit
+		}
+		if len(requested.Buckets) == 0 {
+			requested.Buckets = existing.Buckets
+		}
+	}
+
+	return requested, r.checkConflict(existing, requested)
 }
 
-// GetOrCreateCounter gets an existing counter or creates a new one
+// addDescriptor makes def the descriptor of def.Name when the name has none yet.
+// Must be called with the write lock held.
+func (r *registry) addDescriptor(def Definition) {
+	if _, ok := r.descriptors[def.Name]; !ok {
+		r.descriptors[def.Name] = def
+	}
+}
+
+// matches reports whether a cached instrument created with descriptor created can be
+// returned for unit and buckets without a check, empty ones are taken from created
+func matches(created Definition, unit Unit, buckets []float64) bool {
+	return (unit == "" || unit == created.Unit) && (len(buckets) == 0 || slices.Equal(buckets, created.Buckets))
+}
+
+// reuse compares unit and buckets requested for a cached instrument with the descriptor it
+// was created with, see checkConflict. Empty ones are taken from created.
+// Must be called with the write lock held.
+func (r *registry) reuse(created Definition, unit Unit, buckets []float64) error {
+	requested := created
+	if unit != "" {
+		requested.Unit = unit
+	}
+	if len(buckets) > 0 {
+		requested.Buckets = buckets
+	}
+
+	return r.checkConflict(created, requested)
+}
+
+// GetOrCreateCounter gets an existing counter or creates a new one. Creating it for a name
+// already used with another kind or unit, or getting it with another unit than it was created
+// with, is a conflict: logged once and created or returned anyway, or rejected with a
+// *MetricConflictError in ValidationStrict mode.
 func (r *registry) GetOrCreateCounter(name MetricName, unit Unit, labels map[string]string) (*Counter, error) {
 	key := metricKey(string(name), labels)
 
 	r.mutex.RLock()
-	if counter, exists := r.counters[key]; exists {
+	if counter, exists := r.counters[key]; exists && matches(counter.definition(), unit, nil) {
 		r.mutex.RUnlock()
 		return counter, nil
 	}
@@ -105,18 +230,30 @@ func (r *registry) GetOrCreateCounter(name MetricName, unit Unit, labels map[str
 
 	// Check again after acquiring write lock
 	if counter, exists := r.counters[key]; exists {
+		if err := r.reuse(counter.definition(), unit, nil); err != nil {
+			return nil, err
+		}
 		return counter, nil
 	}
 
+	if err := r.validate(name, unit); err != nil {
+		return nil, err
+	}
+
+	def, err := r.descriptor(name, KindCounter, unit, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create OTEL counter
-	otelCounter, err := r.otelClient.CreateCounter(string(name), string(unit))
+	otelCounter, err := r.otelClient.CreateCounter(string(name), string(def.Unit), def.Description)
 	if err != nil {
 		return nil, ErrCreatingMetric
 	}
 
 	counter := &Counter{
 		name:        name,
-		unit:        unit,
+		unit:        def.Unit,
 		labels:      labels,
 		otelCounter: otelCounter,
 		ctx:         r.ctx,
@@ -125,6 +262,7 @@ func (r *registry) GetOrCreateCounter(name MetricName, unit Unit, labels map[str
 	}
 
 	r.counters[key] = counter
+	r.addDescriptor(def)
 
 	return counter, nil
 }
@@ -134,7 +272,7 @@ func (r *registry) GetOrCreateGauge(name MetricName, unit Unit, labels map[strin
 	key := metricKey(string(name), labels)
 
 	r.mutex.RLock()
-	if gauge, exists := r.gauges[key]; exists {
+	if gauge, exists := r.gauges[key]; exists && matches(gauge.definition(), unit, nil) {
 		r.mutex.RUnlock()
 		return gauge, nil
 	}
@@ -145,11 +283,23 @@ func (r *registry) GetOrCreateGauge(name MetricName, unit Unit, labels map[strin
 
 	// Check again after acquiring write lock
 	if gauge, exists := r.gauges[key]; exists {
+		if err := r.reuse(gauge.definition(), unit, nil); err != nil {
+			return nil, err
+		}
 		return gauge, nil
 	}
 
+	if err := r.validate(name, unit); err != nil {
+		return nil, err
+	}
+
+	def, err := r.descriptor(name, KindGauge, unit, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create OTEL gauge
-	otelGauge, err := r.otelClient.CreateGauge(string(name), string(unit))
+	otelGauge, err := r.otelClient.CreateGauge(string(name), string(def.Unit), def.Description)
 	if err != nil {
 		// Log error but don't fail - return a dummy gauge
 		return nil, ErrCreatingMetric
@@ -157,6 +307,7 @@ func (r *registry) GetOrCreateGauge(name MetricName, unit Unit, labels map[strin
 
 	gauge := &Gauge{
 		name:      name,
+		unit:      def.Unit,
 		labels:    labels,
 		otelGauge: otelGauge,
 		ctx:       r.ctx,
@@ -165,20 +316,21 @@ func (r *registry) GetOrCreateGauge(name MetricName, unit Unit, labels map[strin
 	}
 
 	r.gauges[key] = gauge
+	r.addDescriptor(def)
 
 	return gauge, nil
 }
 
 // GetOrCreateHistogram gets an existing histogram or creates a new one
 func (r *registry) GetOrCreateHistogram(name MetricName, unit Unit, buckets []float64, labels map[string]string) (*Histogram, error) {
-	if len(buckets) > 20 {
+	if len(buckets) > r.limits.MaxHistogramBuckets {
 		return nil, ErrHistBucketSizeTooLarge
 	}
 
 	key := metricKey(string(name), labels)
 
 	r.mutex.RLock()
-	if histogram, exists := r.histograms[key]; exists {
+	if histogram, exists := r.histograms[key]; exists && matches(histogram.definition(), unit, buckets) {
 		r.mutex.RUnlock()
 		return histogram, nil
 	}
@@ -189,17 +341,31 @@ func (r *registry) GetOrCreateHistogram(name MetricName, unit Unit, buckets []fl
 
 	// Check again after acquiring write lock
 	if histogram, exists := r.histograms[key]; exists {
+		if err := r.reuse(histogram.definition(), unit, buckets); err != nil {
+			return nil, err
+		}
 		return histogram, nil
 	}
 
+	if err := r.validate(name, unit); err != nil {
+		return nil, err
+	}
+
+	def, err := r.descriptor(name, KindHistogram, unit, buckets)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create OTEL histogram
-	otelHistogram, err := r.otelClient.CreateHistogram(string(name), string(unit), buckets)
+	otelHistogram, err := r.otelClient.CreateHistogram(string(name), string(def.Unit), def.Description, def.Buckets)
 	if err != nil {
 		return nil, ErrCreatingMetric
 	}
 
 	histogram := &Histogram{
 		name:          name,
+		unit:          def.Unit,
+		buckets:       def.Buckets,
 		labels:        labels,
 		otelHistogram: otelHistogram,
 		ctx:           r.ctx,
@@ -207,6 +373,7 @@ func (r *registry) GetOrCreateHistogram(name MetricName, unit Unit, buckets []fl
 	}
 
 	r.histograms[key] = histogram
+	r.addDescriptor(def)
 
 	return histogram, nil
 }
@@ -226,10 +393,72 @@ func (r *registry) Close() error {
 	r.counters = make(map[string]*Counter)
 	r.gauges = make(map[string]*Gauge)
 	r.histograms = make(map[string]*Histogram)
+	r.warned = make(map[string]bool)
+
+	return nil
+}
+
+// validate checks name and unit in the validation mode of the registry, see report.
+// Must be called with the write lock held.
+func (r *registry) validate(name MetricName, unit Unit) error {
+	if r.limits.Validation == ValidationOff {
+		return nil
+	}
+
+	err := name.Validate()
+	if err == nil {
+		err = unit.Validate()
+	}
+	return r.report(name, err, "metric does not follow the OTEL naming rules")
+}
+
+// checkConflict compares the descriptor requested for a name with its existing one in the
+// validation mode of the registry, see report. Must be called with the write lock held.
+func (r *registry) checkConflict(existing, requested Definition) error {
+	if r.limits.Validation == ValidationOff {
+		return nil
+	}
+
+	return r.report(requested.Name, conflict(existing, requested), "metric conflicts with an earlier use of its name")
+}
+
+// report logs every distinct err once, as a warning or, in strict mode where the metric is
+// dropped, as an error. Only strict mode returns err. Must be called with the write lock held.
+func (r *registry) report(name MetricName, err error, warning string) error {
+	if err == nil {
+		return nil
+	}
+
+	if !r.warned[err.Error()] && r.limits.Logger != nil {
+		r.warned[err.Error()] = true
+		if r.limits.Validation == ValidationStrict {
+			r.limits.Logger.Error("dropping metric", "metric", string(name), "err", err.Error())
+		} else {
+			r.limits.Logger.Warn(warning, "metric", string(name), "err", err.Error())
+		}
+	}
 
+	if r.limits.Validation == ValidationStrict {
+		return err
+	}
 	return nil
 }
 
+// definition returns the descriptor the counter was created with
+func (c *Counter) definition() Definition {
+	return Definition{Name: c.name, Kind: KindCounter, Unit: c.unit}
+}
+
+// definition returns the descriptor the gauge was created with
+func (g *Gauge) definition() Definition {
+	return Definition{Name: g.name, Kind: KindGauge, Unit: g.unit}
+}
+
+// definition returns the descriptor the histogram was created with
+func (h *Histogram) definition() Definition {
+	return Definition{Name: h.name, Kind: KindHistogram, Unit: h.unit, Buckets: h.buckets}
+}
+
 // Inc increments the counter by 1 and returns the new value
 func (c *Counter) Inc() int64 {
 	return c.Add(1)
@@ -17,18 +17,18 @@ type MockOTelClient struct {
 	mock.Mock
 }
 
-func (m *MockOTelClient) CreateCounter(name, unit string) (client.Counter, error) {
-	args := m.Called(name, unit)
+func (m *MockOTelClient) CreateCounter(name, unit, description string) (client.Counter, error) {
+	args := m.Called(name, unit, description)
 	return args.Get(0).(client.Counter), args.Error(1)
 }
 
-func (m *MockOTelClient) CreateGauge(name, unit string) (client.Gauge, error) {
-	args := m.Called(name, unit)
+func (m *MockOTelClient) CreateGauge(name, unit, description string) (client.Gauge, error) {
+	args := m.Called(name, unit, description)
 	return args.Get(0).(client.Gauge), args.Error(1)
 }
 
-func (m *MockOTelClient) CreateHistogram(name, unit string, buckets []float64) (client.Histogram, error) {
-	args := m.Called(name, unit, buckets)
+func (m *MockOTelClient) CreateHistogram(name, unit, description string, buckets []float64) (client.Histogram, error) {
+	args := m.Called(name, unit, description, buckets)
 	return args.Get(0).(client.Histogram), args.Error(1)
 }
 
@@ -96,7 +96,7 @@ func TestRegistry_GetOrCreateCounter(t *testing.T) {
 			unit:       UnitRequest,
 			labels:     map[string]string{"method": "GET"},
 			setupMock: func() {
-				mockClient.On("CreateCounter", string(MetricCounterHttpRequestsTotal), string(UnitRequest)).
+				mockClient.On("CreateCounter", string(MetricCounterHttpRequestsTotal), string(UnitRequest), "").
 					Return(mockCounter, nil).Once()
 			},
 			wantErr: false,
@@ -107,7 +107,7 @@ func TestRegistry_GetOrCreateCounter(t *testing.T) {
 			unit:       UnitRequest,
 			labels:     nil,
 			setupMock: func() {
-				mockClient.On("CreateCounter", "test_counter", string(UnitRequest)).
+				mockClient.On("CreateCounter", "test_counter", string(UnitRequest), "").
 					Return(mockCounter, nil).Once()
 			},
 			wantErr: false,
@@ -118,7 +118,7 @@ func TestRegistry_GetOrCreateCounter(t *testing.T) {
 			unit:       UnitRequest,
 			labels:     map[string]string{"key": "value"},
 			setupMock: func() {
-				mockClient.On("CreateCounter", "failing_counter", string(UnitRequest)).
+				mockClient.On("CreateCounter", "failing_counter", string(UnitRequest), "").
 					Return((*MockCounter)(nil), ErrCreatingMetric).Once()
 			},
 			wantErr: true,
@@ -156,7 +156,7 @@ func TestRegistry_GetOrCreateCounter_CacheHit(t *testing.T) {
 	registry := NewRegistry(mockClient, ctx)
 
 	// Setup mock to expect only one call
-	mockClient.On("CreateCounter", "test_counter", "requests").
+	mockClient.On("CreateCounter", "test_counter", "requests", "").
 		Return(mockCounter, nil).Once()
 
 	labels := map[string]string{"method": "GET"}
@@ -198,7 +198,7 @@ func TestRegistry_GetOrCreateGauge(t *testing.T) {
 			unit:       UnitBytes,
 			labels:     map[string]string{"component": "cache"},
 			setupMock: func() {
-				mockClient.On("CreateGauge", "memory_usage", string(UnitBytes)).
+				mockClient.On("CreateGauge", "memory_usage", string(UnitBytes), "").
 					Return(mockGauge, nil).Once()
 			},
 			wantErr: false,
@@ -209,7 +209,7 @@ func TestRegistry_GetOrCreateGauge(t *testing.T) {
 			unit:       UnitPercent,
 			labels:     map[string]string{"key": "value"},
 			setupMock: func() {
-				mockClient.On("CreateGauge", "failing_gauge", string(UnitPercent)).
+				mockClient.On("CreateGauge", "failing_gauge", string(UnitPercent), "").
 					Return((*MockGauge)(nil), ErrCreatingMetric).Once()
 			},
 			wantErr: true,
@@ -261,7 +261,7 @@ func TestRegistry_GetOrCreateHistogram(t *testing.T) {
 			buckets:    []float64{0.1, 0.5, 1.0, 5.0},
 			labels:     map[string]string{"endpoint": "/api"},
 			setupMock: func() {
-				mockClient.On("CreateHistogram", string(MetricHistHttpRequestDuration), string(UnitSeconds), []float64{0.1, 0.5, 1.0, 5.0}).
+				mockClient.On("CreateHistogram", string(MetricHistHttpRequestDuration), string(UnitSeconds), "", []float64{0.1, 0.5, 1.0, 5.0}).
 					Return(mockHistogram, nil).Once()
 			},
 			wantErr: false,
@@ -282,7 +282,7 @@ func TestRegistry_GetOrCreateHistogram(t *testing.T) {
 			buckets:    []float64{1.0, 5.0},
 			labels:     map[string]string{"key": "value"},
 			setupMock: func() {
-				mockClient.On("CreateHistogram", "failing_histogram", string(UnitSeconds), []float64{1.0, 5.0}).
+				mockClient.On("CreateHistogram", "failing_histogram", string(UnitSeconds), "", []float64{1.0, 5.0}).
 					Return((*MockHistogram)(nil), ErrCreatingMetric).Once()
 			},
 			wantErr: true,
@@ -530,7 +530,7 @@ func TestRegistry_ConcurrentAccess(t *testing.T) {
 	registry := NewRegistry(mockClient, ctx)
 
 	// Setup mock to handle multiple concurrent calls
-	mockClient.On("CreateCounter", "concurrent_counter", "requests").
+	mockClient.On("CreateCounter", "concurrent_counter", "requests", "").
 		Return(mockCounter, nil)
 
 	labels := map[string]string{"worker": "1"}
@@ -650,3 +650,199 @@ func TestHistogram_NilOtelHistogram(t *testing.T) {
 		})
 	})
 }
+
+func TestRegistry_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("histogram buckets", func(t *testing.T) {
+		mockClient := &MockOTelClient{}
+		mockClient.On("CreateHistogram", "latency", "ms", "", []float64{1, 2, 3}).Return(&MockHistogram{}, nil)
+
+		registry := NewRegistryWithLimits(mockClient, ctx, Limits{MaxHistogramBuckets: 3})
+
+		_, err := registry.GetOrCreateHistogram("latency", "ms", []float64{1, 2, 3}, nil)
+		require.NoError(t, err)
+
+		_, err = registry.GetOrCreateHistogram("latency", "ms", []float64{1, 2, 3, 4}, nil)
+		assert.ErrorIs(t, err, ErrHistBucketSizeTooLarge)
+	})
+}
+
+func TestRegistry_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("strict rejects invalid names and units", func(t *testing.T) {
+		mockClient := &MockOTelClient{}
+		mockClient.On("CreateCounter", "http.server.requests", "{request}", "").Return(&MockCounter{}, nil)
+
+		registry := NewRegistryWithLimits(mockClient, ctx, Limits{Validation: ValidationStrict})
+
+		_, err := registry.GetOrCreateCounter("http.server.requests", "{request}", nil)
+		require.NoError(t, err)
+
+		_, err = registry.GetOrCreateCounter("http server requests", "{request}", nil)
+		assert.ErrorIs(t, err, ErrInvalidMetricName)
+
+		_, err = registry.GetOrCreateGauge("queue.depth", "messages", nil)
+		assert.ErrorIs(t, err, ErrInvalidUnit)
+
+		mockClient.AssertNumberOfCalls(t, "CreateCounter", 1)
+		mockClient.AssertNotCalled(t, "CreateGauge", "queue.depth", "messages", "")
+	})
+
+	t.Run("warn creates the metric", func(t *testing.T) {
+		mockClient := &MockOTelClient{}
+		mockClient.On("CreateGauge", "Queue.Depth", "messages", "").Return(&MockGauge{}, nil)
+
+		registry := NewRegistry(mockClient, ctx)
+
+		gauge, err := registry.GetOrCreateGauge("Queue.Depth", "messages", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, gauge)
+	})
+}
+
+func TestRegistry_Register(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("definitions fill in description, unit and buckets", func(t *testing.T) {
+		mockClient := &MockOTelClient{}
+		mockClient.On("CreateCounter", "orders.placed", "{order}", "Orders accepted by checkout").Return(&MockCounter{}, nil)
+		mockClient.On("CreateGauge", "queue.depth", "{message}", "Messages waiting in the queue").Return(&MockGauge{}, nil)
+		mockClient.On("CreateHistogram", "payment.duration", "s", "Time to authorize a payment", []float64{0.1, 0.5, 1}).Return(&MockHistogram{}, nil)
+
+		registry := NewRegistry(mockClient, ctx)
+		require.NoError(t, registry.Register(
+			Definition{Name: "orders.placed", Unit: "{order}", Description: "Orders accepted by checkout", Kind: KindCounter},
+			Definition{Name: "queue.depth", Unit: "{message}", Description: "Messages waiting in the queue", Kind: KindGauge},
+			Definition{Name: "payment.duration", Unit: "s", Description: "Time to authorize a payment", Kind: KindHistogram, Buckets: []float64{0.1, 0.5, 1}},
+		))
+
+		_, err := registry.GetOrCreateCounter("orders.placed", "", nil)
+		require.NoError(t, err)
+		_, err = registry.GetOrCreateGauge("queue.depth", "{message}", nil)
+		require.NoError(t, err)
+		_, err = registry.GetOrCreateHistogram("payment.duration", "", nil, nil)
+		require.NoError(t, err)
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		registry := NewRegistryWithLimits(&MockOTelClient{}, ctx, Limits{MaxHistogramBuckets: 2, Validation: ValidationStrict})
+
+		tests := []struct {
+			def     Definition
+			wantErr error
+		}{
+			{def: Definition{Name: "orders.placed", Kind: "summary"}, wantErr: ErrInvalidDefinition},
+			{def: Definition{Name: "orders.placed", Kind: KindCounter, Buckets: []float64{1}}, wantErr: ErrInvalidDefinition},
+			{def: Definition{Name: "latency", Kind: KindHistogram, Buckets: []float64{5, 1}}, wantErr: ErrInvalidDefinition},
+			{def: Definition{Name: "latency", Kind: KindHistogram, Buckets: []float64{1, 2, 3}}, wantErr: ErrHistBucketSizeTooLarge},
+			{def: Definition{Name: "Orders Placed", Kind: KindCounter}, wantErr: ErrInvalidMetricName},
+		}
+		for _, tt := range tests {
+			assert.ErrorIs(t, registry.Register(tt.def), tt.wantErr)
+		}
+	})
+}
+
+func TestRegistry_Conflicts(t *testing.T) {
+	ctx := context.Background()
+
+	newRegistry := func(mode ValidationMode) (Registry, *MockOTelClient) {
+		mockClient := &MockOTelClient{}
+		mockClient.On("CreateCounter", "jobs", "{job}", "").Return(&MockCounter{}, nil)
+		mockClient.On("CreateHistogram", "latency", "ms", "", []float64{10, 100}).Return(&MockHistogram{}, nil)
+		return NewRegistryWithLimits(mockClient, ctx, Limits{Validation: mode}), mockClient
+	}
+
+	t.Run("strict rejects mismatches", func(t *testing.T) {
+		registry, mockClient := newRegistry(ValidationStrict)
+
+		_, err := registry.GetOrCreateCounter("jobs", "{job}", map[string]string{"queue": "a"})
+		require.NoError(t, err)
+		_, err = registry.GetOrCreateHistogram("latency", "ms", []float64{10, 100}, map[string]string{"route": "/a"})
+		require.NoError(t, err)
+
+		// Empty units and buckets take the ones of the first instrument
+		_, err = registry.GetOrCreateCounter("jobs", "", map[string]string{"queue": "b"})
+		require.NoError(t, err)
+		_, err = registry.GetOrCreateHistogram("latency", "", nil, map[string]string{"route": "/b"})
+		require.NoError(t, err)
+
+		_, err = registry.GetOrCreateGauge("jobs", "{job}", map[string]string{"queue": "c"})
+		var conflict *MetricConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.ErrorIs(t, err, ErrMetricConflict)
+		assert.Equal(t, KindCounter, conflict.Existing.Kind)
+		assert.Equal(t, KindGauge, conflict.Requested.Kind)
+		assert.EqualError(t, err, `metric conflict: "jobs" is a counter, not a gauge`)
+
+		_, err = registry.GetOrCreateCounter("jobs", "s", map[string]string{"queue": "d"})
+		assert.EqualError(t, err, `metric conflict: "jobs" has unit "{job}", not "s"`)
+
+		_, err = registry.GetOrCreateHistogram("latency", "ms", []float64{5, 50}, map[string]string{"route": "/c"})
+		assert.EqualError(t, err, `metric conflict: "latency" has buckets [10 100], not [5 50]`)
+
+		err = registry.Register(Definition{Name: "latency", Unit: "s", Kind: KindHistogram})
+		assert.ErrorIs(t, err, ErrMetricConflict)
+
+		// A matching definition only adds the description
+		require.NoError(t, registry.Register(Definition{Name: "jobs", Unit: "{job}", Description: "Jobs processed", Kind: KindCounter}))
+
+		mockClient.AssertNumberOfCalls(t, "CreateCounter", 2)
+		mockClient.AssertNumberOfCalls(t, "CreateHistogram", 2)
+		mockClient.AssertNotCalled(t, "CreateGauge", "jobs", "{job}", "")
+	})
+
+	t.Run("strict rejects mismatches with identical labels", func(t *testing.T) {
+		registry, mockClient := newRegistry(ValidationStrict)
+		labels := map[string]string{"route": "/a"}
+
+		histogram, err := registry.GetOrCreateHistogram("latency", "ms", []float64{10, 100}, labels)
+		require.NoError(t, err)
+		counter, err := registry.GetOrCreateCounter("jobs", "{job}", labels)
+		require.NoError(t, err)
+
+		// Empty units and buckets return the cached instrument
+		cached, err := registry.GetOrCreateHistogram("latency", "", nil, labels)
+		require.NoError(t, err)
+		assert.Same(t, histogram, cached)
+
+		cached, err = registry.GetOrCreateHistogram("latency", "ms", []float64{5, 10}, labels)
+		assert.Nil(t, cached)
+		assert.EqualError(t, err, `metric conflict: "latency" has buckets [10 100], not [5 10]`)
+
+		cached, err = registry.GetOrCreateHistogram("latency", "s", nil, labels)
+		assert.Nil(t, cached)
+		assert.EqualError(t, err, `metric conflict: "latency" has unit "ms", not "s"`)
+
+		cachedCounter, err := registry.GetOrCreateCounter("jobs", "s", labels)
+		assert.Nil(t, cachedCounter)
+		assert.ErrorIs(t, err, ErrMetricConflict)
+
+		cachedCounter, err = registry.GetOrCreateCounter("jobs", "{job}", labels)
+		require.NoError(t, err)
+		assert.Same(t, counter, cachedCounter)
+
+		mockClient.AssertNumberOfCalls(t, "CreateCounter", 1)
+		mockClient.AssertNumberOfCalls(t, "CreateHistogram", 1)
+	})
+
+	t.Run("warn creates the instrument", func(t *testing.T) {
+		registry, mockClient := newRegistry(ValidationWarn)
+		mockClient.On("CreateGauge", "jobs", "{job}", "").Return(&MockGauge{}, nil)
+
+		_, err := registry.GetOrCreateCounter("jobs", "{job}", nil)
+		require.NoError(t, err)
+		gauge, err := registry.GetOrCreateGauge("jobs", "{job}", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, gauge)
+
+		// A mismatch with identical labels returns the cached instrument
+		counter, err := registry.GetOrCreateCounter("jobs", "s", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, counter)
+	})
+}
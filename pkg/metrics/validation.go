@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidMetricName = errors.New("invalid metric name")
+	ErrInvalidUnit       = errors.New("invalid metric unit")
+)
+
+// ValidationMode decides what a registry does with an invalid metric name or unit
+type ValidationMode string
+
+const (
+	// ValidationOff accepts any name and unit
+	ValidationOff ValidationMode = "off"
+	// ValidationWarn logs an invalid name or unit once and creates the metric anyway
+	ValidationWarn ValidationMode = "warn"
+	// ValidationStrict rejects an invalid name or unit with an error wrapping ErrInvalidMetricName or ErrInvalidUnit
+	ValidationStrict ValidationMode = "strict"
+)
+
+const (
+	// MaxMetricNameLength is the longest instrument name OTEL accepts
+	MaxMetricNameLength = 255
+	// MaxUnitLength is the longest unit OTEL accepts
+	MaxUnitLength = 63
+)
+
+// metricNamePattern is the OTEL instrument name syntax
+var metricNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_./-]*$`)
+
+// Validate checks the name against the OTEL instrument naming rules. Names are also
+// required to be lowercase: OTEL compares them case-insensitively and mixed-case names
+// are rewritten by Prometheus, so Http.Requests and http.requests end up as one series.
+func (n MetricName) Validate() error {
+	name := string(n)
+
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name is empty", ErrInvalidMetricName)
+	case len(name) > MaxMetricNameLength:
+		return fmt.Errorf("%w: %.32q... is %d characters long, the maximum is %d", ErrInvalidMetricName, name, len(name), MaxMetricNameLength)
+	case !isASCIILetter(rune(name[0])):
+		return fmt.Errorf("%w: %q must start with a letter", ErrInvalidMetricName, name)
+	case !metricNamePattern.MatchString(name):
+		for i, r := range name {
+			if !isASCIILetter(r) && (r < '0' || r > '9') && !strings.ContainsRune("_./-", r) {
+				return fmt.Errorf("%w: %q contains %q at position %d, only letters, digits, '_', '.', '/' and '-' are allowed", ErrInvalidMetricName, name, r, i)
+			}
+		}
+	case strings.ToLower(name) != name:
+		return fmt.Errorf("%w: %q contains uppercase letters, use %q", ErrInvalidMetricName, name, strings.ToLower(name))
+	}
+
+	return nil
+}
+
+// Validate checks the unit against the case sensitive UCUM syntax, e.g. "ms", "By/s",
+// "1" or "{request}". An empty unit means the metric is dimensionless and is valid.
+func (u Unit) Validate() error {
+	unit := string(u)
+
+	switch {
+	case unit == "":
+		return nil
+	case len(unit) > MaxUnitLength:
+		return fmt.Errorf("%w: %.32q... is %d characters long, the maximum is %d", ErrInvalidUnit, unit, len(unit), MaxUnitLength)
+	}
+	for i, r := range unit {
+		if r < '!' || r > '~' {
+			return fmt.Errorf("%w: %q contains %q at position %d, only printable ASCII without spaces is allowed", ErrInvalidUnit, unit, r, i)
+		}
+	}
+
+	p := &unitParser{unit: unit}
+	if err := p.parse(); err != nil {
+		return fmt.Errorf("%w: %q %v", ErrInvalidUnit, unit, err)
+	}
+
+	return nil
+}
+
+// ucumPrefixes are the UCUM prefixes, applicable to metricUnits only
+var ucumPrefixes = []string{
+	"Y", "Z", "E", "P", "T", "G", "M", "k", "h", "da", "d", "c", "m", "u", "n", "p", "f", "a", "z", "y",
+	"Ki", "Mi", "Gi", "Ti", "Pi", "Ei",
+}
+
+// metricUnits are the UCUM atoms that take a prefix
+var metricUnits = map[string]bool{
+	"m": true, "g": true, "s": true, "rad": true, "K": true, "C": true, "cd": true,
+	"mol": true, "sr": true, "Hz": true, "N": true, "Pa": true, "J": true, "W": true,
+	"A": true, "V": true, "F": true, "Ohm": true, "S": true, "Wb": true, "Cel": true,
+	"T": true, "H": true, "lm": true, "lx": true, "Bq": true, "Gy": true, "Sv": true,
+	"l": true, "L": true, "t": true, "eV": true, "bar": true, "B": true,
+	"By": true, "bit": true, "Bd": true, "cal": true,
+}
+
+// otherUnits are the UCUM atoms used by metrics that take no prefix
+var otherUnits = map[string]bool{
+	"1": true, "%": true, "10*": true, "10^": true, "min": true, "h": true, "d": true,
+	"wk": true, "mo": true, "a": true, "deg": true, "'": true, "''": true, "ar": true,
+}
+
+// unitParser is a recursive descent parser of the UCUM grammar:
+//
+//	term      = ['/'] component { ('.' | '/') component }
+//	component = annotatable [annotation] | factor [annotation] | annotation | '(' term ')' [annotation]
+type unitParser struct {
+	unit string
+	pos  int
+}
+
+func (p *unitParser) parse() error {
+	if err := p.term(); err != nil {
+		return err
+	}
+	if p.pos < len(p.unit) {
+		return fmt.Errorf("has an unexpected %q at position %d", p.unit[p.pos], p.pos)
+	}
+	return nil
+}
+
+func (p *unitParser) term() error {
+	if p.peek() == '/' {
+		p.pos++
+	}
+	for {
+		if err := p.component(); err != nil {
+			return err
+		}
+		if c := p.peek(); c != '.' && c != '/' {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+func (p *unitParser) component() error {
+	switch p.peek() {
+	case 0:
+		return fmt.Errorf("is missing a unit at position %d", p.pos)
+	case '{':
+		return p.annotation()
+	case '(':
+		p.pos++
+		if err := p.term(); err != nil {
+			return err
+		}
+		if p.peek() != ')' {
+			return fmt.Errorf("is missing ')' at position %d", p.pos)
+		}
+		p.pos++
+	default:
+		if err := p.annotatable(); err != nil {
+			return err
+		}
+	}
+
+	if p.peek() == '{' {
+		return p.annotation()
+	}
+	return nil
+}
+
+func (p *unitParser) annotation() error {
+	end := strings.IndexByte(p.unit[p.pos:], '}')
+	if end < 0 {
+		return fmt.Errorf("is missing '}' for the annotation at position %d", p.pos)
+	}
+	if strings.ContainsRune(p.unit[p.pos+1:p.pos+end], '{') {
+		return fmt.Errorf("has a nested annotation at position %d", p.pos)
+	}
+	p.pos += end + 1
+	return nil
+}
+
+func (p *unitParser) annotatable() error {
+	start := p.pos
+	for p.pos < len(p.unit) && !strings.ContainsRune("./(){}", rune(p.unit[p.pos])) {
+		p.pos++
+	}
+	symbol := p.unit[start:p.pos]
+
+	// A factor is a number on its own, "10*" and "10^" are powers of ten followed by an exponent
+	if isDigits(symbol) {
+		return nil
+	}
+	for _, power := range []string{"10*", "10^"} {
+		if strings.HasPrefix(symbol, power) && isExponent(symbol[len(power):]) {
+			return nil
+		}
+	}
+
+	// Square bracketed atoms are the customary UCUM units, e.g. [in_i], and are not checked further
+	if strings.HasPrefix(symbol, "[") {
+		if !strings.HasSuffix(trimExponent(symbol), "]") {
+			return fmt.Errorf("has an unterminated '[' at position %d", start)
+		}
+		return nil
+	}
+
+	atom := trimExponent(symbol)
+	if isUnitAtom(atom) {
+		return nil
+	}
+	return fmt.Errorf("has an unknown unit %q, use a UCUM unit such as \"s\", \"By\" or \"1\", or an annotation such as \"{%s}\"", atom, atom)
+}
+
+func (p *unitParser) peek() byte {
+	if p.pos < len(p.unit) {
+		return p.unit[p.pos]
+	}
+	return 0
+}
+
+// isUnitAtom reports whether atom is a known UCUM unit, with a prefix if it is a metric unit
+func isUnitAtom(atom string) bool {
+	if metricUnits[atom] || otherUnits[atom] {
+		return true
+	}
+	for _, prefix := range ucumPrefixes {
+		if rest, ok := strings.CutPrefix(atom, prefix); ok && metricUnits[rest] {
+			return true
+		}
+	}
+	return false
+}
+
+// trimExponent removes a trailing signed integer exponent, e.g. the 2 of m2 or the -1 of s-1
+func trimExponent(symbol string) string {
+	i := len(symbol)
+	for i > 0 && symbol[i-1] >= '0' && symbol[i-1] <= '9' {
+		i--
+	}
+	if i == len(symbol) || i == 0 {
+		return symbol
+	}
+	if symbol[i-1] == '+' || symbol[i-1] == '-' {
+		i--
+	}
+	return symbol[:i]
+}
+
+func isExponent(s string) bool {
+	return isDigits(strings.TrimLeft(s, "+-")) && len(strings.TrimLeft(s, "+-")) >= len(s)-1
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
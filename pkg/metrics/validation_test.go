@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricName_Validate(t *testing.T) {
+	tests := []struct {
+		name    MetricName
+		wantErr string
+	}{
+		{name: MetricCounterHttpRequestsTotal},
+		{name: "db.client.connections.usage"},
+		{name: "queue_depth"},
+		{name: "kafka/consumer-lag"},
+		{name: "", wantErr: "name is empty"},
+		{name: "http requests", wantErr: `contains ' ' at position 4`},
+		{name: "9xx.responses", wantErr: "must start with a letter"},
+		{name: "_private", wantErr: "must start with a letter"},
+		{name: "latency.p99:ms", wantErr: `contains ':'`},
+		{name: "Http.Server.Requests", wantErr: `use "http.server.requests"`},
+		{name: MetricName(strings.Repeat("a", 300)), wantErr: "300 characters long, the maximum is 255"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			err := tt.name.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidMetricName)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUnit_Validate(t *testing.T) {
+	tests := []struct {
+		unit    Unit
+		wantErr string
+	}{
+		{unit: ""},
+		{unit: UnitPercent},
+		{unit: UnitSeconds},
+		{unit: UnitMilliseconds},
+		{unit: UnitBytes},
+		{unit: UnitRequest},
+		{unit: "1"},
+		{unit: "KiBy"},
+		{unit: "By/s"},
+		{unit: "/s"},
+		{unit: "{packet}/s"},
+		{unit: "m2"},
+		{unit: "m.s-2"},
+		{unit: "10*3"},
+		{unit: "kg{dry}"},
+		{unit: "(By/s).h"},
+		{unit: "[in_i]"},
+		{unit: "seconds", wantErr: `unknown unit "seconds"`},
+		{unit: "requests", wantErr: `"{requests}"`},
+		{unit: "By/", wantErr: "missing a unit at position 3"},
+		{unit: "{request", wantErr: "missing '}'"},
+		{unit: "(By/s", wantErr: "missing ')'"},
+		{unit: "kilo byte", wantErr: `contains ' ' at position 4`},
+		{unit: "µs", wantErr: "only printable ASCII"},
+		{unit: Unit("{" + strings.Repeat("x", 70) + "}"), wantErr: "the maximum is 63"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.unit), func(t *testing.T) {
+			err := tt.unit.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidUnit)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
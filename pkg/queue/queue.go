@@ -0,0 +1,342 @@
+// Package queue provides a persistent on-disk queue for OTLP export batches.
+// Batches that could not be delivered while the collector was unreachable are
+// written to a directory and replayed with backoff once the endpoint recovers.
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GetSimpl/gotel/pkg/clock"
+)
+
+const (
+	batchFileExt = ".batch"
+	tmpFileExt   = ".tmp"
+)
+
+var (
+	ErrBatchTooLarge = errors.New("batch is larger than the queue size limit")
+	ErrQueueClosed   = errors.New("queue is closed")
+)
+
+// Batch is a single OTLP export request as it was sent over the wire
+type Batch struct {
+	URL             string    `json:"url"`
+	ContentType     string    `json:"content_type"`
+	ContentEncoding string    `json:"content_encoding,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	Body            []byte    `json:"-"`
+}
+
+// Item is a batch read back from the queue together with its storage name
+type Item struct {
+	name  string
+	Batch Batch
+}
+
+// entry is the in-memory index record for a batch stored on disk
+type entry struct {
+	name      string
+	size      int64
+	createdAt time.Time
+}
+
+// Queue is a bounded FIFO of export batches persisted in a directory.
+// The size bound drops the oldest batches first, the age bound expires batches
+// that are too old to be useful to the backend.
+type Queue struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+	entries  []entry // oldest first
+	size     int64
+	seq      uint64
+	closed   bool
+	logger   *slog.Logger
+	clock    clock.Clock
+	mutex    sync.Mutex
+}
+
+// discardLogger is used when no logger is given
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// orDiscard returns l, or a logger discarding everything when l is nil
+func orDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
+// Open opens the queue stored in dir, creating the directory if needed.
+// Batches left over from a previous process are picked up in their original order.
+// Dropped batches are not logged, the queue of a Transport logs to Options.Logger.
+func Open(dir string, maxBytes int64, maxAge time.Duration) (*Queue, error) {
+	return open(dir, maxBytes, maxAge, clock.System)
+}
+
+// open opens the queue stored in dir, batches are created and expired by the time of clk
+func open(dir string, maxBytes int64, maxAge time.Duration, clk clock.Clock) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	q := &Queue{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		logger:   discardLogger,
+		clock:    clk,
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// load indexes batch files already present in the queue directory
+func (q *Queue) load() error {
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() {
+			continue
+		}
+
+		// Leftovers of interrupted writes are never valid batches
+		if strings.HasSuffix(name, tmpFileExt) {
+			_ = os.Remove(filepath.Join(q.dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, batchFileExt) {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		var nanos int64
+		if _, err := fmt.Sscanf(name, "%d-", &nanos); err != nil {
+			continue
+		}
+
+		q.entries = append(q.entries, entry{name: name, size: info.Size(), createdAt: time.Unix(0, nanos)})
+		q.size += info.Size()
+	}
+
+	// File names start with a fixed-width timestamp, so lexical order is creation order
+	sort.Slice(q.entries, func(i, j int) bool {
+		return q.entries[i].name < q.entries[j].name
+	})
+
+	q.pruneLocked(q.clock.Now(), 0)
+
+	return nil
+}
+
+// Push appends a batch to the queue, evicting the oldest batches if the size limit is exceeded
+func (q *Queue) Push(batch Batch) error {
+	if batch.CreatedAt.IsZero() {
+		batch.CreatedAt = q.clock.Now()
+	}
+
+	data, err := encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	size := int64(len(data))
+	if q.maxBytes > 0 && size > q.maxBytes {
+		return ErrBatchTooLarge
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	q.pruneLocked(q.clock.Now(), size)
+
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d%s", batch.CreatedAt.UnixNano(), q.seq%1000000, batchFileExt)
+	path := filepath.Join(q.dir, name)
+
+	// Write to a temporary file first so a crash never leaves a truncated batch behind
+	tmpPath := path + tmpFileExt
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write batch: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	q.entries = append(q.entries, entry{name: name, size: size, createdAt: batch.CreatedAt})
+	q.size += size
+
+	return nil
+}
+
+// Oldest returns the oldest batch in the queue, or nil if the queue is empty.
+// The batch stays in the queue until Remove is called with the returned item.
+func (q *Queue) Oldest() (*Item, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for {
+		q.pruneLocked(q.clock.Now(), 0)
+
+		if len(q.entries) == 0 {
+			return nil, nil
+		}
+
+		oldest := q.entries[0]
+		batch, err := readBatch(filepath.Join(q.dir, oldest.name))
+		if err != nil {
+			// A corrupt batch can never be delivered, drop it and move on
+			q.logger.Error("dropping unreadable queued batch", "file", oldest.name, "err", err.Error())
+			q.removeLocked(oldest.name)
+			continue
+		}
+
+		return &Item{name: oldest.name, Batch: batch}, nil
+	}
+}
+
+// Remove deletes a batch returned by Oldest from the queue
+func (q *Queue) Remove(item *Item) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.removeLocked(item.name)
+}
+
+// Len returns the number of batches waiting in the queue
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.entries)
+}
+
+// Size returns the number of bytes used by the queued batches
+func (q *Queue) Size() int64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.size
+}
+
+// Close stops the queue from accepting new batches. Stored batches are kept on disk.
+func (q *Queue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+
+	return nil
+}
+
+// pruneLocked drops expired batches and, if needed, the oldest batches so that
+// a new batch of incoming bytes still fits within the size limit
+func (q *Queue) pruneLocked(now time.Time, incoming int64) {
+	for len(q.entries) > 0 {
+		oldest := q.entries[0]
+
+		expired := q.maxAge > 0 && now.Sub(oldest.createdAt) > q.maxAge
+		overflow := q.maxBytes > 0 && q.size+incoming > q.maxBytes
+		if !expired && !overflow {
+			return
+		}
+
+		if expired {
+			q.logger.Warn("dropping expired queued batch", "file", oldest.name, "age", now.Sub(oldest.createdAt).String())
+		} else {
+			q.logger.Warn("dropping queued batch to stay within size limit", "file", oldest.name, "maxBytes", q.maxBytes)
+		}
+
+		q.removeLocked(oldest.name)
+	}
+}
+
+// removeLocked deletes the named batch from disk and from the index
+func (q *Queue) removeLocked(name string) {
+	for i, e := range q.entries {
+		if e.name != name {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(q.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			q.logger.Error("failed to remove queued batch", "file", name, "err", err.Error())
+		}
+
+		q.size -= e.size
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		return
+	}
+}
+
+// encodeBatch serializes a batch as a JSON header line followed by the raw request body
+func encodeBatch(batch Batch) ([]byte, error) {
+	header, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch header: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(header) + 1 + len(batch.Body))
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(batch.Body)
+
+	return buf.Bytes(), nil
+}
+
+// readBatch reads a batch written by encodeBatch
+func readBatch(path string) (Batch, error) {
+	var batch Batch
+
+	file, err := os.Open(path)
+	if err != nil {
+		return batch, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header, err := reader.ReadBytes('\n')
+	if err != nil {
+		return batch, fmt.Errorf("failed to read batch header: %w", err)
+	}
+
+	if err := json.Unmarshal(header, &batch); err != nil {
+		return batch, fmt.Errorf("failed to decode batch header: %w", err)
+	}
+
+	if batch.Body, err = io.ReadAll(reader); err != nil {
+		return batch, fmt.Errorf("failed to read batch body: %w", err)
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GetSimpl/gotel/pkg/logger"
+)
+
+func TestQueue_PushOldestRemove(t *testing.T) {
+	logger.InitLogger()
+
+	q, err := Open(t.TempDir(), 1<<20, time.Hour)
+	require.NoError(t, err)
+
+	item, err := q.Oldest()
+	require.NoError(t, err)
+	assert.Nil(t, item, "empty queue should have no oldest item")
+
+	for _, body := range []string{"first", "second", "third"} {
+		require.NoError(t, q.Push(Batch{
+			URL:         "http://collector/v1/metrics",
+			ContentType: "application/x-protobuf",
+			Body:        []byte(body),
+		}))
+	}
+	assert.Equal(t, 3, q.Len())
+
+	for _, expected := range []string{"first", "second", "third"} {
+		item, err := q.Oldest()
+		require.NoError(t, err)
+		require.NotNil(t, item)
+
+		assert.Equal(t, expected, string(item.Batch.Body))
+		assert.Equal(t, "http://collector/v1/metrics", item.Batch.URL)
+		assert.Equal(t, "application/x-protobuf", item.Batch.ContentType)
+
+		q.Remove(item)
+	}
+
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, int64(0), q.Size())
+}
+
+func TestQueue_SizeLimit(t *testing.T) {
+	logger.InitLogger()
+
+	dir := t.TempDir()
+	body := make([]byte, 100)
+
+	// Room for two batches including their headers, but not three
+	q, err := Open(dir, 500, time.Hour)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.Push(Batch{URL: "http://collector/v1/metrics", Body: append([]byte{byte('a' + i)}, body...)}))
+	}
+
+	assert.Equal(t, 2, q.Len(), "oldest batch should be evicted")
+	assert.LessOrEqual(t, q.Size(), int64(500))
+
+	item, err := q.Oldest()
+	require.NoError(t, err)
+	assert.Equal(t, byte('b'), item.Batch.Body[0])
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	t.Run("batch larger than the limit is rejected", func(t *testing.T) {
+		err := q.Push(Batch{Body: make([]byte, 1000)})
+		assert.ErrorIs(t, err, ErrBatchTooLarge)
+		assert.Equal(t, 2, q.Len())
+	})
+}
+
+func TestQueue_AgeLimit(t *testing.T) {
+	logger.InitLogger()
+
+	q, err := Open(t.TempDir(), 1<<20, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Push(Batch{Body: []byte("stale"), CreatedAt: time.Now().Add(-2 * time.Minute)}))
+	require.NoError(t, q.Push(Batch{Body: []byte("fresh")}))
+
+	item, err := q.Oldest()
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "fresh", string(item.Batch.Body))
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestQueue_ReopenKeepsBatches(t *testing.T) {
+	logger.InitLogger()
+
+	dir := t.TempDir()
+
+	q, err := Open(dir, 1<<20, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(Batch{Body: []byte("one"), ContentEncoding: "gzip"}))
+	require.NoError(t, q.Push(Batch{Body: []byte("two")}))
+	require.NoError(t, q.Close())
+
+	// Interrupted writes and foreign files must not be picked up
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000001-000001.batch.tmp"), []byte("partial"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	reopened, err := Open(dir, 1<<20, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+
+	item, err := reopened.Oldest()
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(item.Batch.Body))
+	assert.Equal(t, "gzip", item.Batch.ContentEncoding)
+
+	assert.NoFileExists(t, filepath.Join(dir, "00000000000000000001-000001.batch.tmp"))
+}
+
+func TestQueue_CorruptBatchIsDropped(t *testing.T) {
+	logger.InitLogger()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000001-000001.batch"), []byte("no header"), 0o644))
+
+	q, err := Open(dir, 1<<20, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(Batch{Body: []byte("valid")}))
+
+	item, err := q.Oldest()
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "valid", string(item.Batch.Body))
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestQueue_ClosedRejectsPush(t *testing.T) {
+	logger.InitLogger()
+
+	q, err := Open(t.TempDir(), 1<<20, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	assert.ErrorIs(t, q.Push(Batch{Body: []byte("late")}), ErrQueueClosed)
+}
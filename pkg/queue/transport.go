@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GetSimpl/gotel/pkg/clock"
+)
+
+// Options configures the persistent export queue
+type Options struct {
+	// Dir is the directory where failed batches are stored
+	Dir string
+	// MaxBytes bounds the total size of stored batches, oldest batches are dropped first
+	MaxBytes int64
+	// MaxAge drops stored batches older than this duration
+	MaxAge time.Duration
+	// InitialBackoff is the wait before the first replay retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential wait between replay retries
+	MaxBackoff time.Duration
+	// RequestTimeout bounds a single replay request
+	RequestTimeout time.Duration
+	// Debug enables logging of every queued and replayed batch
+	Debug bool
+	// Logger receives dropped batches and, with Debug, queued and replayed ones. Nothing
+	// is logged when nil.
+	Logger *slog.Logger
+	// Clock dates and expires batches and times the replay backoff, clock.System when nil
+	Clock clock.Clock
+}
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultRequestTimeout = 10 * time.Second
+)
+
+// Transport is an http.RoundTripper for the OTLP exporter that stores batches
+// the collector could not accept and replays them in order once it recovers.
+// A queued batch is reported to the exporter as accepted so the SDK does not drop it.
+type Transport struct {
+	base    http.RoundTripper
+	queue   *Queue
+	options Options
+	wake    chan struct{}
+	ctx     context.Context // canceled by Close, ends the replay loop and its request in flight
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewTransport opens the queue described by opts and starts replaying any stored batches through base
+func NewTransport(base http.RoundTripper, opts Options) (*Transport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaultInitialBackoff
+	}
+	if opts.MaxBackoff < opts.InitialBackoff {
+		opts.MaxBackoff = max(defaultMaxBackoff, opts.InitialBackoff)
+	}
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	opts.Logger = orDiscard(opts.Logger)
+	opts.Clock = clock.OrSystem(opts.Clock)
+
+	q, err := open(opts.Dir, opts.MaxBytes, opts.MaxAge, opts.Clock)
+	if err != nil {
+		return nil, err
+	}
+	q.logger = opts.Logger
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		base:    base,
+		queue:   q,
+		options: opts,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	t.wg.Add(1)
+	go t.replay()
+
+	if opts.Debug && q.Len() > 0 {
+		t.options.Logger.Info("replaying queued OTLP batches", "count", q.Len(), "bytes", q.Size())
+	}
+
+	return t, nil
+}
+
+// Queue returns the underlying on-disk queue
+func (t *Transport) Queue() *Queue {
+	return t.queue
+}
+
+// RoundTrip sends the export request, queueing it on disk if the collector is unavailable
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := Batch{
+		URL:             req.URL.String(),
+		ContentType:     req.Header.Get("Content-Type"),
+		ContentEncoding: req.Header.Get("Content-Encoding"),
+		Body:            body,
+	}
+
+	// Keep batches in order: while older batches wait for replay, newer ones queue up behind them
+	if t.queue.Len() > 0 {
+		return t.enqueue(req, batch, nil)
+	}
+
+	resp, err := t.base.RoundTrip(withBody(req, body))
+	if err != nil {
+		return t.enqueue(req, batch, err)
+	}
+
+	if isRetryable(resp.StatusCode) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return t.enqueue(req, batch, fmt.Errorf("collector responded with %s", resp.Status))
+	}
+
+	return resp, nil
+}
+
+// Close stops the replay loop, aborting a replay request in flight. Batches still queued,
+// including the one being replayed, stay on disk for the next process.
+func (t *Transport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+
+	return t.queue.Close()
+}
+
+// enqueue stores the batch and reports it to the exporter as accepted
+func (t *Transport) enqueue(req *http.Request, batch Batch, cause error) (*http.Response, error) {
+	if err := t.queue.Push(batch); err != nil {
+		if cause != nil {
+			return nil, fmt.Errorf("%w (failed to queue batch: %v)", cause, err)
+		}
+		return nil, fmt.Errorf("failed to queue batch: %w", err)
+	}
+
+	if t.options.Debug {
+		if cause != nil {
+			t.options.Logger.Info("queued OTLP batch after failed export", "bytes", len(batch.Body), "queued", t.queue.Len(), "err", cause.Error())
+		} else {
+			t.options.Logger.Info("queued OTLP batch behind pending batches", "bytes", len(batch.Body), "queued", t.queue.Len())
+		}
+	}
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+
+	return &http.Response{
+		Status:     "202 Accepted",
+		StatusCode: http.StatusAccepted,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+// replay delivers queued batches oldest first, backing off exponentially while the collector is down
+func (t *Transport) replay() {
+	defer t.wg.Done()
+
+	backoff := t.options.InitialBackoff
+
+	for {
+		item, err := t.queue.Oldest()
+		if err != nil || item == nil {
+			select {
+			case <-t.wake:
+				continue
+			case <-t.ctx.Done():
+				return
+			}
+		}
+
+		if err := t.deliver(item.Batch); err != nil {
+			if t.options.Debug {
+				t.options.Logger.Info("replay of queued OTLP batch failed", "retryIn", backoff.String(), "err", err.Error())
+			}
+
+			select {
+			case <-t.options.Clock.After(backoff):
+			case <-t.ctx.Done():
+				return
+			}
+
+			backoff = min(backoff*2, t.options.MaxBackoff)
+			continue
+		}
+
+		t.queue.Remove(item)
+		backoff = t.options.InitialBackoff
+
+		if t.options.Debug {
+			t.options.Logger.Info("replayed queued OTLP batch", "bytes", len(item.Batch.Body), "remaining", t.queue.Len())
+		}
+	}
+}
+
+// deliver sends a single queued batch. Batches rejected for good are treated as delivered.
+func (t *Transport) deliver(batch Batch) error {
+	ctx, cancel := context.WithTimeout(t.ctx, t.options.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, batch.URL, bytes.NewReader(batch.Body))
+	if err != nil {
+		t.options.Logger.Error("dropping queued batch with invalid request", "err", err.Error())
+		return nil
+	}
+
+	req.Header.Set("Content-Type", batch.ContentType)
+	if batch.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", batch.ContentEncoding)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case isRetryable(resp.StatusCode):
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	default:
+		// Retrying a batch the collector refuses would block the queue forever
+		t.options.Logger.Error("dropping queued batch rejected by collector", "status", resp.Status)
+		return nil
+	}
+}
+
+// isRetryable reports whether the status code is one the OTLP exporter would retry
+func isRetryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// readBody drains and closes the request body
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	return io.ReadAll(req.Body)
+}
+
+// withBody clones the request with a replayable copy of body
+func withBody(req *http.Request, body []byte) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return clone
+}
@@ -0,0 +1,337 @@
+package queue
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GetSimpl/gotel/pkg/logger"
+)
+
+// dropConnection makes the flaky collector close connections without responding
+const dropConnection = -1
+
+// flakyCollector is an httptest OTLP endpoint that fails on purpose until told to recover
+type flakyCollector struct {
+	server   *httptest.Server
+	failWith atomic.Int32 // status code to fail with, 0 means healthy
+	mutex    sync.Mutex
+	received []string
+	encoding []string
+}
+
+func newFlakyCollector(t *testing.T, failWith int) *flakyCollector {
+	c := &flakyCollector{}
+	c.failWith.Store(int32(failWith))
+
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		switch status := c.failWith.Load(); status {
+		case 0:
+		case dropConnection:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		default:
+			w.WriteHeader(int(status))
+			return
+		}
+
+		c.mutex.Lock()
+		c.received = append(c.received, string(body))
+		c.encoding = append(c.encoding, r.Header.Get("Content-Encoding"))
+		c.mutex.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(c.server.Close)
+
+	return c
+}
+
+func (c *flakyCollector) Received() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string(nil), c.received...)
+}
+
+func newTestTransport(t *testing.T, dir string) *Transport {
+	transport, err := NewTransport(http.DefaultTransport, Options{
+		Dir:            dir,
+		MaxBytes:       1 << 20,
+		MaxAge:         time.Hour,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		RequestTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	return transport
+}
+
+func post(t *testing.T, client *http.Client, url, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestTransport_PassThroughWhenHealthy(t *testing.T) {
+	logger.InitLogger()
+
+	collector := newFlakyCollector(t, 0)
+	transport := newTestTransport(t, t.TempDir())
+	defer transport.Close()
+
+	client := &http.Client{Transport: transport}
+
+	resp := post(t, client, collector.server.URL+"/v1/metrics", "batch-1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"batch-1"}, collector.Received())
+	assert.Equal(t, 0, transport.Queue().Len())
+}
+
+func TestTransport_QueuesAndReplaysDuringOutage(t *testing.T) {
+	tests := []struct {
+		name     string
+		failWith int
+	}{
+		{name: "service unavailable", failWith: http.StatusServiceUnavailable},
+		{name: "too many requests", failWith: http.StatusTooManyRequests},
+		{name: "gateway timeout", failWith: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.InitLogger()
+
+			collector := newFlakyCollector(t, tt.failWith)
+			transport := newTestTransport(t, t.TempDir())
+			defer transport.Close()
+
+			client := &http.Client{Transport: transport}
+
+			// Failed batches are reported as accepted so the exporter does not drop them
+			for _, body := range []string{"batch-1", "batch-2", "batch-3"} {
+				resp := post(t, client, collector.server.URL+"/v1/metrics", body)
+				assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+			}
+
+			assert.Empty(t, collector.Received())
+			assert.Equal(t, 3, transport.Queue().Len())
+
+			// Collector recovers, queued batches are replayed in order
+			collector.failWith.Store(0)
+
+			require.Eventually(t, func() bool {
+				return transport.Queue().Len() == 0
+			}, 5*time.Second, 10*time.Millisecond)
+
+			assert.Equal(t, []string{"batch-1", "batch-2", "batch-3"}, collector.Received())
+
+			collector.mutex.Lock()
+			assert.Equal(t, []string{"gzip", "gzip", "gzip"}, collector.encoding)
+			collector.mutex.Unlock()
+		})
+	}
+}
+
+func TestTransport_QueuesWhenConnectionFailsAndReplaysAfterRestart(t *testing.T) {
+	logger.InitLogger()
+
+	dir := t.TempDir()
+	collector := newFlakyCollector(t, dropConnection)
+
+	transport := newTestTransport(t, dir)
+	client := &http.Client{Transport: transport}
+
+	resp := post(t, client, collector.server.URL+"/v1/metrics", "offline-batch")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, 1, transport.Queue().Len())
+
+	// Process exits while the collector is still down, the batch stays on disk
+	require.NoError(t, transport.Close())
+	assert.Empty(t, collector.Received())
+
+	collector.failWith.Store(0)
+
+	restarted := newTestTransport(t, dir)
+	defer restarted.Close()
+
+	require.Eventually(t, func() bool {
+		return restarted.Queue().Len() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"offline-batch"}, collector.Received())
+}
+
+func TestTransport_NonRetryableStatusIsReturned(t *testing.T) {
+	logger.InitLogger()
+
+	collector := newFlakyCollector(t, http.StatusBadRequest)
+	transport := newTestTransport(t, t.TempDir())
+	defer transport.Close()
+
+	client := &http.Client{Transport: transport}
+
+	resp := post(t, client, collector.server.URL+"/v1/metrics", "bad-batch")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, 0, transport.Queue().Len(), "batches the collector rejects should not be queued")
+}
+
+func TestTransport_WithoutLogger(t *testing.T) {
+	logger.Logger = nil
+
+	collector := newFlakyCollector(t, http.StatusServiceUnavailable)
+	transport, err := NewTransport(http.DefaultTransport, Options{
+		Dir:            t.TempDir(),
+		MaxBytes:       250, // room for one batch
+		MaxAge:         time.Hour,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		RequestTimeout: time.Second,
+		Debug:          true,
+	})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	client := &http.Client{Transport: transport}
+
+	// Queueing, dropping to stay within the size limit and replaying log nowhere
+	post(t, client, collector.server.URL+"/v1/metrics", "batch-1")
+	post(t, client, collector.server.URL+"/v1/metrics", "batch-2")
+	assert.Equal(t, 1, transport.Queue().Len())
+
+	collector.failWith.Store(0)
+	require.Eventually(t, func() bool {
+		return transport.Queue().Len() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"batch-2"}, collector.Received())
+}
+
+// manualClock is a clock.Clock that only moves and fires when the test says so. After
+// reports every wait on waiting before returning tick.
+type manualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiting chan time.Duration
+	tick    chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.waiting <- d
+	return c.tick
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestTransport_WithClock(t *testing.T) {
+	logger.InitLogger()
+
+	clk := &manualClock{now: time.Now(), waiting: make(chan time.Duration), tick: make(chan time.Time)}
+	collector := newFlakyCollector(t, http.StatusServiceUnavailable)
+	transport, err := NewTransport(http.DefaultTransport, Options{
+		Dir:            t.TempDir(),
+		MaxBytes:       1 << 20,
+		MaxAge:         time.Hour,
+		InitialBackoff: time.Hour,
+		RequestTimeout: time.Second,
+		Clock:          clk,
+	})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	client := &http.Client{Transport: transport}
+
+	post(t, client, collector.server.URL+"/v1/metrics", "stale")
+	assert.Equal(t, time.Hour, <-clk.waiting, "the replay should back off on the clock")
+
+	// The first batch expires by the time of the clock, the second is replayed once it ticks
+	clk.Advance(2 * time.Hour)
+	collector.failWith.Store(0)
+	post(t, client, collector.server.URL+"/v1/metrics", "fresh")
+	clk.tick <- clk.Now()
+
+	require.Eventually(t, func() bool {
+		return transport.Queue().Len() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"fresh"}, collector.Received())
+}
+
+func TestTransport_CloseAbortsReplay(t *testing.T) {
+	logger.InitLogger()
+
+	// A collector that accepts the connection and never answers
+	replaying := make(chan struct{}, 1)
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replaying <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	dir := t.TempDir()
+	q, err := Open(dir, 1<<20, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(Batch{URL: collector.URL + "/v1/metrics", Body: []byte("pending")}))
+	require.NoError(t, q.Close())
+
+	transport, err := NewTransport(http.DefaultTransport, Options{
+		Dir:            dir,
+		MaxBytes:       1 << 20,
+		MaxAge:         time.Hour,
+		RequestTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+
+	<-replaying
+
+	closed := make(chan error, 1)
+	go func() { closed <- transport.Close() }()
+
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the replay request to time out")
+	}
+
+	// The aborted batch stays queued for the next process
+	reopened, err := Open(dir, 1<<20, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reopened.Len())
+}
@@ -0,0 +1,305 @@
+// Package tlsconfig builds the TLS configuration for exporter transports.
+// CA bundles and client certificates are read from disk and reloaded when the
+// files change, so rotated certificates are used without restarting the client.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNoCertificates = errors.New("no certificates found in CA file")
+	ErrKeyPairMissing = errors.New("client certificate and key must be set together")
+)
+
+// Options describes the TLS settings of an exporter transport
+type Options struct {
+	// CAFile is a PEM bundle used instead of the system roots to verify the server
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mTLS
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name used to verify the server certificate
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification, for development only
+	InsecureSkipVerify bool
+}
+
+// Enabled reports whether any TLS setting differs from the Go defaults
+func (o Options) Enabled() bool {
+	return o.CAFile != "" || o.CertFile != "" || o.KeyFile != "" || o.ServerName != "" || o.InsecureSkipVerify
+}
+
+// New returns a tls.Config for opts. The client certificate is reloaded on every
+// handshake if its files changed, the CA bundle is read once. Use NewTransport to
+// also pick up a rotated CA bundle.
+func New(opts Options) (*tls.Config, error) {
+	b, err := newBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := b.build()
+	return cfg, err
+}
+
+// Transport is an http.RoundTripper that applies the TLS options and swaps in a
+// new connection pool whenever the CA bundle changes on disk
+type Transport struct {
+	base    *http.Transport
+	builder *builder
+	current atomic.Pointer[pooledTransport]
+	mutex   sync.Mutex
+}
+
+// pooledTransport is an http.Transport together with the CA pool it verifies against
+type pooledTransport struct {
+	transport *http.Transport
+	roots     *x509.CertPool
+}
+
+// NewTransport returns a transport that behaves like base with the TLS options applied
+func NewTransport(base *http.Transport, opts Options) (*Transport, error) {
+	b, err := newBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transport{
+		base:    base,
+		builder: b,
+	}
+
+	if _, err := t.transport(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// RoundTrip sends the request over a transport that trusts the current CA bundle
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.transport()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	return transport.RoundTrip(req)
+}
+
+// CloseIdleConnections closes idle connections of the current transport
+func (t *Transport) CloseIdleConnections() {
+	if current := t.current.Load(); current != nil {
+		current.transport.CloseIdleConnections()
+	}
+}
+
+// transport returns the http.Transport for the current CA bundle, rebuilding it after a rotation
+func (t *Transport) transport() (*http.Transport, error) {
+	roots, err := t.builder.rootCAs()
+	if err != nil {
+		return nil, err
+	}
+
+	if current := t.current.Load(); current != nil && current.roots == roots {
+		return current.transport, nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	previous := t.current.Load()
+	if previous != nil && previous.roots == roots {
+		return previous.transport, nil
+	}
+
+	cfg, roots, err := t.builder.build()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := t.base.Clone()
+	transport.TLSClientConfig = cfg
+	t.current.Store(&pooledTransport{transport: transport, roots: roots})
+
+	// Connections verified against the old bundle are not reused once idle
+	if previous != nil {
+		previous.transport.CloseIdleConnections()
+	}
+
+	return transport, nil
+}
+
+// builder creates tls.Configs from options, caching the files they are built from
+type builder struct {
+	options Options
+	roots   *fileCache[*x509.CertPool]   // nil when the system roots are used
+	keyPair *fileCache[*tls.Certificate] // nil without mTLS
+}
+
+// newBuilder validates opts and loads the configured files once so misconfiguration fails fast
+func newBuilder(opts Options) (*builder, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, ErrKeyPairMissing
+	}
+
+	b := &builder{options: opts}
+
+	if opts.CertFile != "" {
+		b.keyPair = &fileCache[*tls.Certificate]{
+			paths: []string{opts.CertFile, opts.KeyFile},
+			load:  loadKeyPair(opts.CertFile, opts.KeyFile),
+		}
+		if _, err := b.keyPair.get(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CAFile != "" {
+		b.roots = &fileCache[*x509.CertPool]{
+			paths: []string{opts.CAFile},
+			load:  loadCertPool(opts.CAFile),
+		}
+		if _, err := b.roots.get(); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// rootCAs returns the current CA pool, nil meaning the system roots
+func (b *builder) rootCAs() (*x509.CertPool, error) {
+	if b.roots == nil {
+		return nil, nil
+	}
+	return b.roots.get()
+}
+
+// build returns a tls.Config trusting the current CA pool, along with that pool
+func (b *builder) build() (*tls.Config, *x509.CertPool, error) {
+	roots, err := b.rootCAs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            roots,
+		ServerName:         b.options.ServerName,
+		InsecureSkipVerify: b.options.InsecureSkipVerify,
+	}
+
+	if b.keyPair != nil {
+		keyPair := b.keyPair
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.get()
+		}
+	}
+
+	return cfg, roots, nil
+}
+
+// loadKeyPair returns a loader for the client certificate and key
+func loadKeyPair(certFile, keyFile string) func() (*tls.Certificate, error) {
+	return func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		return &cert, nil
+	}
+}
+
+// loadCertPool returns a loader for a PEM CA bundle
+func loadCertPool(caFile string) func() (*x509.CertPool, error) {
+	return func() (*x509.CertPool, error) {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, ErrNoCertificates
+		}
+		return pool, nil
+	}
+}
+
+// fileStamp identifies a version of a file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileCache holds a value loaded from files and reloads it when any of them change.
+// If a reload fails, e.g. while a rotation is only half written, the last good value is kept.
+type fileCache[T any] struct {
+	paths  []string
+	load   func() (T, error)
+	value  T
+	stamps []fileStamp
+	loaded bool
+	mutex  sync.Mutex
+}
+
+// get returns the cached value, reloading it first if the files changed
+func (c *fileCache[T]) get() (T, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stamps := make([]fileStamp, len(c.paths))
+	for i, path := range c.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if c.loaded {
+				return c.value, nil
+			}
+			return c.value, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	if c.loaded && equalStamps(stamps, c.stamps) {
+		return c.value, nil
+	}
+
+	value, err := c.load()
+	if err != nil {
+		if c.loaded {
+			return c.value, nil
+		}
+		return value, err
+	}
+
+	c.value = value
+	c.stamps = stamps
+	c.loaded = true
+
+	return c.value, nil
+}
+
+// equalStamps reports whether two sets of file stamps describe the same file versions
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gotel test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issueClientCert returns PEM encoded certificate and key for a client with the given common name
+func (ca *testCA) issueClientCert(t *testing.T, commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data and bumps the modification time so rotations are detected on coarse filesystems
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	stamp := time.Now().Add(time.Duration(len(data)) * time.Second)
+	require.NoError(t, os.Chtimes(path, stamp, stamp))
+}
+
+// serverCAPEM returns the self-signed certificate of an httptest TLS server as a PEM bundle
+func serverCAPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func get(transport http.RoundTripper, url string) error {
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestNewTransport_ServerVerification(t *testing.T) {
+	// The httptest certificate is valid for 127.0.0.1 and example.com
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, serverCAPEM(server))
+
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{
+			name:    "trusted CA bundle",
+			options: Options{CAFile: caFile},
+		},
+		{
+			name:    "server name override matching the certificate",
+			options: Options{CAFile: caFile, ServerName: "example.com"},
+		},
+		{
+			name:    "server name override not matching the certificate",
+			options: Options{CAFile: caFile, ServerName: "collector.internal"},
+			wantErr: true,
+		},
+		{
+			name:    "system roots do not trust the test server",
+			options: Options{ServerName: "example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "insecure skip verify",
+			options: Options{InsecureSkipVerify: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(http.DefaultTransport.(*http.Transport).Clone(), tt.options)
+			require.NoError(t, err)
+			defer transport.CloseIdleConnections()
+
+			err = get(transport, server.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewTransport_ReloadsRotatedCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, newTestCA(t).pem)
+
+	transport, err := NewTransport(http.DefaultTransport.(*http.Transport).Clone(), Options{CAFile: caFile})
+	require.NoError(t, err)
+	defer transport.CloseIdleConnections()
+
+	assert.Error(t, get(transport, server.URL), "unrelated CA must not be trusted")
+
+	writeFile(t, caFile, serverCAPEM(server))
+
+	assert.NoError(t, get(transport, server.URL), "rotated CA bundle should be picked up")
+}
+
+func TestNewTransport_MutualTLS(t *testing.T) {
+	clientCA := newTestCA(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+
+	var lastClient atomic.Value
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastClient.Store(r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+
+	writeFile(t, caFile, serverCAPEM(server))
+	certPEM, keyPEM := clientCA.issueClientCert(t, "client-a")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	t.Run("without client certificate", func(t *testing.T) {
+		transport, err := NewTransport(http.DefaultTransport.(*http.Transport).Clone(), Options{CAFile: caFile})
+		require.NoError(t, err)
+		defer transport.CloseIdleConnections()
+
+		assert.Error(t, get(transport, server.URL))
+	})
+
+	t.Run("client certificate is presented and reloaded on rotation", func(t *testing.T) {
+		transport, err := NewTransport(http.DefaultTransport.(*http.Transport).Clone(), Options{
+			CAFile:   caFile,
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		})
+		require.NoError(t, err)
+		defer transport.CloseIdleConnections()
+
+		require.NoError(t, get(transport, server.URL))
+		assert.Equal(t, "client-a", lastClient.Load())
+
+		certPEM, keyPEM := clientCA.issueClientCert(t, "client-b")
+		writeFile(t, certFile, certPEM)
+		writeFile(t, keyFile, keyPEM)
+
+		// The rotated certificate is used from the next handshake on
+		transport.CloseIdleConnections()
+
+		require.NoError(t, get(transport, server.URL))
+		assert.Equal(t, "client-b", lastClient.Load())
+	})
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.pem")
+	writeFile(t, garbage, []byte("not a certificate"))
+
+	tests := []struct {
+		name    string
+		options Options
+		wantErr error
+	}{
+		{
+			name:    "certificate without key",
+			options: Options{CertFile: garbage},
+			wantErr: ErrKeyPairMissing,
+		},
+		{
+			name:    "CA file without certificates",
+			options: Options{CAFile: garbage},
+			wantErr: ErrNoCertificates,
+		},
+		{
+			name:    "missing CA file",
+			options: Options{CAFile: filepath.Join(dir, "missing.pem")},
+		},
+		{
+			name:    "invalid key pair",
+			options: Options{CertFile: garbage, KeyFile: garbage},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := New(tt.options)
+			require.Error(t, err)
+			assert.Nil(t, cfg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}